@@ -1,21 +1,68 @@
 package phpdoc
 
-import "mibk.dev/phpdoc/phptype"
+import (
+	"strings"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+// A Node is the interface implemented by all nodes of a PHPDoc
+// syntax tree.
+type Node interface {
+	Pos() phptype.Pos // position of the first character belonging to the node
+	End() phptype.Pos // position of the first character immediately after the node
+}
+
+// node records the source range of a node. Both positions are zero
+// for nodes that weren't created by the parser.
+type node struct {
+	StartPos, EndPos phptype.Pos
+}
+
+func (n *node) Pos() phptype.Pos { return n.StartPos }
+func (n *node) End() phptype.Pos { return n.EndPos }
+
+func (n *node) setSpan(pos, end phptype.Pos) {
+	n.StartPos, n.EndPos = pos, end
+}
 
 // A Block represents a PHPDoc comment block.
 type Block struct {
+	node
 	Lines         []Line
 	Indent        string // … each line
 	PreferOneline bool
 }
 
+// Has reports whether b contains a flag tag named name, such as
+// internal or inheritDoc. The vendor prefix and case are ignored, so
+// Has("impure") matches @phpstan-impure.
+func (b *Block) Has(name string) bool {
+	_, name = splitVendor(strings.ToLower(name))
+	for _, line := range b.Lines {
+		if f, ok := line.(*FlagTag); ok && strings.EqualFold(f.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // A Line represents a line in a PHPDoc comment.
-type Line interface{ aLine() }
+type Line interface {
+	Node
+	aLine()
+}
 
-type line struct{}
+type line struct {
+	node
+	descPos []phptype.Pos // start of each line of the description
+}
 
 func (*line) aLine() {}
 
+func (l *line) descStarts() []phptype.Pos       { return l.descPos }
+func (l *line) setDescStarts(pos []phptype.Pos) { l.descPos = pos }
+
 // A TextLine represents a regular, text-only line in a PHPDoc comment.
 type TextLine struct {
 	line
@@ -23,6 +70,16 @@ type TextLine struct {
 }
 
 // A Tag represents a tag line in a PHPDoc comment (e.g. @author).
+//
+// The description of a tag continues on the following lines that are
+// indented more than the tag itself. The line breaks are preserved in
+// the description, as well as the indentation relative to the first
+// continuation line. A description that starts on the line following
+// the tag begins with a line break.
+//
+// The tags that have tool-specific variants, such as @phpstan-param
+// or @psalm-return, store the prefix (phpstan, psalm, or phan) in the
+// Vendor field. It's empty for the standard tags.
 type Tag interface {
 	Line
 	aTag()
@@ -36,21 +93,24 @@ func (*tag) aTag() {}
 // A ParamTag represents a @param tag.
 type ParamTag struct {
 	tag
-	Param *phptype.Param
-	Desc  string
+	Vendor string
+	Param  *phptype.Param
+	Desc   string
 }
 
 // A ReturnTag represents a @return tag.
 type ReturnTag struct {
 	tag
-	Type phptype.Type
-	Desc string
+	Vendor string
+	Type   phptype.Type
+	Desc   string
 }
 
 // A PropertyTag represents a @property tag, as well as its variants
 // @property-read and @property-write.
 type PropertyTag struct {
 	tag
+	Vendor              string
 	ReadOnly, WriteOnly bool
 	Type                phptype.Type
 	Var                 string
@@ -60,6 +120,7 @@ type PropertyTag struct {
 // A MethodTag represents a @method tag.
 type MethodTag struct {
 	tag
+	Vendor string
 	Static bool
 	Result phptype.Type // or nil
 	Name   string
@@ -70,28 +131,32 @@ type MethodTag struct {
 // A VarTag represents a @var tag.
 type VarTag struct {
 	tag
-	Type phptype.Type
-	Var  string
-	Desc string
+	Vendor string
+	Type   phptype.Type
+	Var    string
+	Desc   string
 }
 
 // A ThrowsTag represents a @throws tag.
 type ThrowsTag struct {
 	tag
-	Class phptype.Type
-	Desc  string
+	Vendor string
+	Class  phptype.Type
+	Desc   string
 }
 
 // An ExtendsTag represents an @extends tag.
 type ExtendsTag struct {
 	tag
-	Class phptype.Type
-	Desc  string
+	Vendor string
+	Class  phptype.Type
+	Desc   string
 }
 
 // An ImplementsTag represents an @implements tag.
 type ImplementsTag struct {
 	tag
+	Vendor    string
 	Interface phptype.Type
 	Desc      string
 }
@@ -99,26 +164,134 @@ type ImplementsTag struct {
 // A UsesTag represents a @uses tag.
 type UsesTag struct {
 	tag
-	Trait phptype.Type
-	Desc  string
+	Vendor string
+	Trait  phptype.Type
+	Desc   string
 }
 
-// A TemplateTag represents a @template tag.
+// A TemplateTag represents a @template tag, as well as its variants
+// @template-covariant and @template-contravariant.
 type TemplateTag struct {
 	tag
-	Param string
-	Bound phptype.Type // or nil
-	Desc  string
+	Vendor   string
+	Variance phptype.Variance
+	Param    string
+	Bound    phptype.Type // or nil
+	Default  phptype.Type // or nil
+	Desc     string
 }
 
-// A TemplateTag represents a @phpstan-type tag.
+// A TypeDefTag represents a @phpstan-type or @psalm-type tag.
 type TypeDefTag struct {
 	tag
-	Name string
-	Type phptype.Type
+	Vendor string
+	Name   string
+	Type   phptype.Type
+	Desc   string
+}
+
+// An ImportTypeTag represents a @phpstan-import-type or
+// @psalm-import-type tag.
+type ImportTypeTag struct {
+	tag
+	Vendor string
+	Name   string
+	From   *phptype.Named
+	Alias  string // or ""
+	Desc   string
+}
+
+// A MixinTag represents a @mixin tag.
+type MixinTag struct {
+	tag
+	Vendor string
+	Class  phptype.Type
+	Desc   string
+}
+
+// A ParamOutTag represents a @param-out tag.
+type ParamOutTag struct {
+	tag
+	Vendor string
+	Type   phptype.Type
+	Var    string
+	Desc   string
+}
+
+// An AssertTag represents a @phpstan-assert or @psalm-assert tag,
+// as well as their -if-true and -if-false variants.
+type AssertTag struct {
+	tag
+	Vendor          string
+	IfTrue, IfFalse bool
+	Negated         bool // !Type
+	Equal           bool // =Type
+	Type            phptype.Type
+	Var             string
+	Desc            string
+}
+
+// A SelfOutTag represents a @phpstan-self-out or @psalm-self-out tag.
+type SelfOutTag struct {
+	tag
+	Vendor string
+	Type   phptype.Type
+	Desc   string
+}
+
+// A FlagTag represents a tag without arguments that marks a property
+// of the documented element, e.g. @internal, @final, or @inheritDoc.
+type FlagTag struct {
+	tag
+	Vendor string
+	Name   string // without the vendor prefix, as written
+	Desc   string
+}
+
+// A DeprecatedTag represents a @deprecated tag.
+type DeprecatedTag struct {
+	tag
+	Version string // or ""
+	Desc    string
+}
+
+// A SinceTag represents a @since tag.
+type SinceTag struct {
+	tag
+	Version string // or ""
+	Desc    string
+}
+
+// A VersionTag represents a @version tag.
+type VersionTag struct {
+	tag
+	Version string // or ""
+	Desc    string
+}
+
+// A SeeTag represents a @see tag. The reference is either
+// a structural element name (e.g. Foo::bar()), or a URL.
+type SeeTag struct {
+	tag
+	Ref  string
+	Desc string
+}
+
+// A LinkTag represents a @link tag.
+type LinkTag struct {
+	tag
+	URI  string
 	Desc string
 }
 
+// A BadTag is a placeholder for a tag containing syntax errors for
+// which a correct tag node cannot be created. It's only created by
+// the parser in the AllErrors mode.
+type BadTag struct {
+	tag
+	Text string // raw source text of the tag
+}
+
 // A OtherTag represents an arbitrary tag without a special meaning.
 type OtherTag struct {
 	tag
@@ -126,6 +299,41 @@ type OtherTag struct {
 	Desc string
 }
 
+// A Description is a description of a line split into text and
+// inline tags. See ParseDescription.
+type Description struct {
+	node
+	Parts []DescPart
+}
+
+// A DescPart is a part of a Description, i.e. either a *DescText,
+// or an *InlineTag.
+type DescPart interface {
+	Node
+	aDescPart()
+}
+
+type descPart struct{ node }
+
+func (*descPart) aDescPart() {}
+
+// A DescText represents plain text in a description.
+type DescText struct {
+	descPart
+	Text string
+}
+
+// An InlineTag represents an inline tag in a description, e.g.
+// {@link https://example.com label} or {@inheritDoc}. The first word
+// following @link and @see is stored in Ref, otherwise all the text
+// is stored in Desc.
+type InlineTag struct {
+	descPart
+	Name string // without @
+	Ref  string
+	Desc string
+}
+
 func (t *ParamTag) desc() string      { return t.Desc }
 func (t *ReturnTag) desc() string     { return t.Desc }
 func (t *PropertyTag) desc() string   { return t.Desc }
@@ -137,4 +345,16 @@ func (t *ImplementsTag) desc() string { return t.Desc }
 func (t *UsesTag) desc() string       { return t.Desc }
 func (t *TemplateTag) desc() string   { return t.Desc }
 func (t *TypeDefTag) desc() string    { return t.Desc }
+func (t *ImportTypeTag) desc() string { return t.Desc }
+func (t *MixinTag) desc() string      { return t.Desc }
+func (t *ParamOutTag) desc() string   { return t.Desc }
+func (t *AssertTag) desc() string     { return t.Desc }
+func (t *SelfOutTag) desc() string    { return t.Desc }
+func (t *FlagTag) desc() string       { return t.Desc }
+func (t *DeprecatedTag) desc() string { return t.Desc }
+func (t *SinceTag) desc() string      { return t.Desc }
+func (t *VersionTag) desc() string    { return t.Desc }
+func (t *SeeTag) desc() string        { return t.Desc }
+func (t *LinkTag) desc() string       { return t.Desc }
+func (t *BadTag) desc() string        { return "" }
 func (t *OtherTag) desc() string      { return t.Desc }
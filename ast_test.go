@@ -0,0 +1,53 @@
+package phpdoc_test
+
+import (
+	"strings"
+	"testing"
+
+	"mibk.dev/phpdoc"
+)
+
+func TestBlockHas(t *testing.T) {
+	const doc = `/**
+ * @internal
+ * @phpstan-impure
+ * @inheritdoc
+ * @final Not really.
+ * @author Jack
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"internal", true},
+		{"INTERNAL", true},
+		{"impure", true},
+		{"phpstan-impure", true},
+		{"psalm-impure", true},
+		{"inheritDoc", true},
+		{"final", true},
+		{"api", false},
+		{"pure", false},
+		{"author", false},
+	}
+	for _, tt := range tests {
+		if got := block.Has(tt.name); got != tt.want {
+			t.Errorf("Has(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockHasPsalmInternal(t *testing.T) {
+	block, err := phpdoc.Parse(strings.NewReader(`/** @psalm-internal Foo\Bar */`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Has("internal") {
+		t.Error("@psalm-internal with a namespace reported as @internal")
+	}
+}
@@ -0,0 +1,133 @@
+package phpdoc
+
+import (
+	"fmt"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+// Clone returns a deep copy of the block b, including all the PHP
+// types it contains.
+func (b *Block) Clone() *Block {
+	c := *b
+	if b.Lines != nil {
+		c.Lines = make([]Line, len(b.Lines))
+		for i, line := range b.Lines {
+			c.Lines[i] = cloneLine(line)
+			d := c.Lines[i].(describer)
+			d.setDescStarts(append([]phptype.Pos(nil), d.descStarts()...))
+		}
+	}
+	return &c
+}
+
+func cloneLine(line Line) Line {
+	switch l := line.(type) {
+	case *TextLine:
+		c := *l
+		return &c
+	case *ParamTag:
+		c := *l
+		if l.Param != nil {
+			c.Param = phptype.Clone(l.Param).(*phptype.Param)
+		}
+		return &c
+	case *ReturnTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *PropertyTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *MethodTag:
+		c := *l
+		c.Result = cloneType(l.Result)
+		c.Params = phptype.CloneParams(l.Params)
+		return &c
+	case *VarTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *ThrowsTag:
+		c := *l
+		c.Class = cloneType(l.Class)
+		return &c
+	case *ExtendsTag:
+		c := *l
+		c.Class = cloneType(l.Class)
+		return &c
+	case *ImplementsTag:
+		c := *l
+		c.Interface = cloneType(l.Interface)
+		return &c
+	case *UsesTag:
+		c := *l
+		c.Trait = cloneType(l.Trait)
+		return &c
+	case *TemplateTag:
+		c := *l
+		c.Bound = cloneType(l.Bound)
+		c.Default = cloneType(l.Default)
+		return &c
+	case *TypeDefTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *ImportTypeTag:
+		c := *l
+		if l.From != nil {
+			c.From = phptype.Clone(l.From).(*phptype.Named)
+		}
+		return &c
+	case *MixinTag:
+		c := *l
+		c.Class = cloneType(l.Class)
+		return &c
+	case *ParamOutTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *AssertTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *SelfOutTag:
+		c := *l
+		c.Type = cloneType(l.Type)
+		return &c
+	case *FlagTag:
+		c := *l
+		return &c
+	case *DeprecatedTag:
+		c := *l
+		return &c
+	case *SinceTag:
+		c := *l
+		return &c
+	case *VersionTag:
+		c := *l
+		return &c
+	case *SeeTag:
+		c := *l
+		return &c
+	case *LinkTag:
+		c := *l
+		return &c
+	case *BadTag:
+		c := *l
+		return &c
+	case *OtherTag:
+		c := *l
+		return &c
+	default:
+		panic(fmt.Sprintf("phpdoc: unexpected line type %T", l))
+	}
+}
+
+func cloneType(t phptype.Type) phptype.Type {
+	if t == nil {
+		return nil
+	}
+	return phptype.Clone(t).(phptype.Type)
+}
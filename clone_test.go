@@ -0,0 +1,75 @@
+package phpdoc_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestBlockClone(t *testing.T) {
+	const doc = `/**
+ * @param Foo $a
+ * @method Foo bar(Foo $x = 1)
+ * @template T of Foo
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := block.Clone()
+	phpdoc.Inspect(clone, func(n phpdoc.Node) bool {
+		switch n := n.(type) {
+		case *phptype.Named:
+			n.Parts[0] = "Bar"
+		case *phptype.Literal:
+			n.Value = "2"
+		case *phpdoc.TemplateTag:
+			n.Param = "U"
+		}
+		return true
+	})
+
+	print := func(b *phpdoc.Block) string {
+		var buf strings.Builder
+		if err := phpdoc.Fprint(&buf, b); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	const orig = `/**
+ * @param    Foo $a
+ * @method   Foo bar(Foo $x = 1)
+ * @template T of Foo
+ */
+`
+	if got := print(block); got != orig {
+		t.Errorf("original modified:\n got %s\nwant %s", got, orig)
+	}
+	const want = `/**
+ * @param    Bar $a
+ * @method   Bar bar(Bar $x = 2)
+ * @template U of Bar
+ */
+`
+	if got := print(clone); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestBlockClonePartial(t *testing.T) {
+	block := &phpdoc.Block{Lines: []phpdoc.Line{
+		&phpdoc.ParamTag{Desc: "no param"},
+		&phpdoc.ReturnTag{},
+		&phpdoc.ImportTypeTag{Vendor: "phpstan", Name: "Foo", Alias: "Bar"},
+	}}
+	clone := block.Clone()
+	allowUnexportedFields := cmp.Exporter(func(reflect.Type) bool { return true })
+	if diff := cmp.Diff(clone, block, allowUnexportedFields); diff != "" {
+		t.Errorf("clone differs: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,273 @@
+// Phpdocfmt formats PHPDoc comments in PHP source files.
+//
+// Without an explicit path, it processes the standard input. Given a
+// file, it operates on that file; given a directory, it operates on
+// all .php files in that directory, recursively. By default,
+// phpdocfmt prints the reformatted sources to standard output.
+//
+// Only doc comments are reformatted; all the other bytes are left
+// untouched. Doc comments that cannot be parsed are left untouched,
+// too, as well as doc comments with CRLF line endings, for which
+// a warning is printed.
+//
+// Usage:
+//
+//	phpdocfmt [flags] [path ...]
+//
+// The flags are:
+//
+//	-d
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different than phpdocfmt's, print diffs
+//		to standard output.
+//	-e
+//		Report syntax errors in doc comments.
+//	-l
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different from phpdocfmt's, print its name
+//		to standard output, and exit with a non-zero status.
+//	-w
+//		Do not print reformatted sources to standard output.
+//		If a file's formatting is different from phpdocfmt's, overwrite it
+//		with phpdocfmt's version.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"mibk.dev/phpdoc"
+)
+
+var (
+	list      = flag.Bool("l", false, "list files whose formatting differs from phpdocfmt's")
+	write     = flag.Bool("w", false, "write result to (source) file instead of stdout")
+	doDiff    = flag.Bool("d", false, "display diffs instead of rewriting files")
+	allErrors = flag.Bool("e", false, "report syntax errors in doc comments")
+)
+
+var (
+	exitCode           = 0
+	stderr   io.Writer = os.Stderr
+)
+
+func report(err error) {
+	fmt.Fprintln(stderr, err)
+	exitCode = 2
+}
+
+func usage() {
+	fmt.Fprintf(stderr, "usage: phpdocfmt [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "error: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		if err := processFile("<standard input>", os.Stdin, os.Stdout); err != nil {
+			report(err)
+		}
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		switch fi, err := os.Stat(path); {
+		case err != nil:
+			report(err)
+		case fi.IsDir():
+			walkDir(path)
+		default:
+			if err := processFile(path, nil, os.Stdout); err != nil {
+				report(err)
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func walkDir(path string) {
+	filepath.Walk(path, func(path string, f os.FileInfo, err error) error {
+		if err == nil && isPHPFile(f) {
+			err = processFile(path, nil, os.Stdout)
+		}
+		// Don't complain if a file was deleted in the meantime.
+		if err != nil && !os.IsNotExist(err) {
+			report(err)
+		}
+		return nil
+	})
+}
+
+func isPHPFile(f os.FileInfo) bool {
+	// Ignore non-PHP files and hidden files.
+	name := f.Name()
+	return !f.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".php")
+}
+
+// If in == nil, the source is the contents of the file with the given
+// filename.
+func processFile(filename string, in io.Reader, out io.Writer) error {
+	if in == nil {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	res, crlf, err := format(src)
+	if res == nil {
+		return err
+	}
+	if err != nil && *allErrors {
+		reportSyntaxErrors(filename, err)
+	}
+	if crlf {
+		fmt.Fprintf(stderr, "%s: warning: doc comments with CRLF line endings left unformatted\n", filename)
+	}
+
+	if !bytes.Equal(src, res) {
+		if *list {
+			fmt.Fprintln(out, filename)
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+		if *write {
+			fi, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filename, res, fi.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if *doDiff {
+			data, err := diff(src, res, filename)
+			if err != nil {
+				return fmt.Errorf("computing diff: %s", err)
+			}
+			fmt.Fprintf(out, "diff -u %s %s\n", filepath.ToSlash(filename+".orig"), filepath.ToSlash(filename))
+			out.Write(data)
+		}
+	}
+
+	if !*list && !*write && !*doDiff {
+		_, err := out.Write(res)
+		return err
+	}
+	return nil
+}
+
+func reportSyntaxErrors(filename string, err error) {
+	list, ok := err.(phpdoc.ErrorList)
+	if !ok {
+		report(err)
+		return
+	}
+	for _, err := range list {
+		report(fmt.Errorf("%s:%d:%d: %v", filename, err.Line, err.Column, err.Err))
+	}
+}
+
+// format reformats the doc comments in the PHP source src. Comments
+// that cannot be parsed are left untouched; the syntax errors are
+// returned along with the result. Comments with CRLF line endings are
+// left untouched, too, which is reported by crlf.
+func format(src []byte) (res []byte, crlf bool, err error) {
+	f, err := phpdoc.ParseFile(bytes.NewReader(src), 0)
+	if f == nil {
+		return nil, false, err
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, c := range f.Comments {
+		start, end := c.Pos().Offset, c.End().Offset
+		if c.Block == nil {
+			continue
+		}
+		if bytes.IndexByte(src[start:end], '\r') >= 0 {
+			crlf = true
+			continue
+		}
+
+		var out strings.Builder
+		if err := phpdoc.Fprint(&out, c.Block); err != nil {
+			return nil, false, err
+		}
+		text := strings.TrimPrefix(out.String(), c.Indent)
+		text = strings.TrimSuffix(text, "\n")
+		if c.Indent == "" && c.Pos().Column > 1 && strings.Contains(text, "\n") {
+			// Don't spread comments sharing a line with other
+			// code across multiple lines.
+			continue
+		}
+
+		buf.Write(src[last:start])
+		buf.WriteString(text)
+		last = end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), crlf, err
+}
+
+func writeTempFile(dir, prefix string, data []byte) (string, error) {
+	file, err := ioutil.TempFile(dir, prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func diff(b1, b2 []byte, filename string) (data []byte, err error) {
+	f1, err := writeTempFile("", "phpdocfmt", b1)
+	if err != nil {
+		return
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("", "phpdocfmt", b2)
+	if err != nil {
+		return
+	}
+	defer os.Remove(f2)
+
+	data, err = exec.Command("diff", "-u",
+		"--label", filepath.ToSlash(filename+".orig"),
+		"--label", filepath.ToSlash(filename),
+		f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't
+		// match. Ignore that failure as long as we get output.
+		return data, nil
+	}
+	return
+}
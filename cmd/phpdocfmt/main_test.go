@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	const input = `<?php
+/** Model. */
+class Model
+{
+	/**
+		@param  int|string $id  The ID
+	@return  ? self
+	*/
+	public function find($id) { /** @var  int $x*/ return 1; }
+
+	/** @var  array<int ,string> */
+	private $names = '/**  @var  int */';
+
+	/**
+	 * @param broken<int $x
+	 */
+	public function broken($x) {}
+
+	public function inline(/**
+	@param int $x */ $x) {}
+}
+`
+	const want = `<?php
+/** Model. */
+class Model
+{
+	/**
+	 * @param  int|string $id The ID
+	 * @return ?self
+	 */
+	public function find($id) { /** @var int $x */ return 1; }
+
+	/** @var array<int, string> */
+	private $names = '/**  @var  int */';
+
+	/**
+	 * @param broken<int $x
+	 */
+	public function broken($x) {}
+
+	public function inline(/**
+	@param int $x */ $x) {}
+}
+`
+	got, crlf, err := format([]byte(input))
+	if err == nil {
+		t.Error("expecting syntax error")
+	}
+	if crlf {
+		t.Error("unexpected CRLF report")
+	}
+	if string(got) != want {
+		t.Errorf("\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	const (
+		formatted   = "<?php\n/** @var int */\n$x = 1;\n"
+		unformatted = "<?php\n/** @var  int */\n$x = 1;\n"
+		crlf        = "<?php\r\n/**\r\n@var  int\r\n*/\r\n$x = 1;\r\n"
+	)
+	tests := []struct {
+		name     string
+		flag     *bool
+		src      string
+		wantOut  string
+		wantErr  string
+		wantCode int
+	}{
+		{"list formatted", list, formatted, "", "", 0},
+		{"list unformatted", list, unformatted, "a.php\n", "", 1},
+		{"list crlf", list, crlf, "", "a.php: warning: doc comments with CRLF line endings left unformatted\n", 0},
+		{"diff formatted", doDiff, formatted, "", "", 0},
+		{"diff unformatted", doDiff, unformatted, "diff -u a.php.orig a.php\n" +
+			"--- a.php.orig\n" +
+			"+++ a.php\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" <?php\n" +
+			"-/** @var  int */\n" +
+			"+/** @var int */\n" +
+			" $x = 1;\n", "", 0},
+	}
+	defer func(w io.Writer) { stderr = w }(stderr)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*tt.flag = true
+			defer func() { *tt.flag = false }()
+			exitCode = 0
+			var out, errOut strings.Builder
+			stderr = &errOut
+
+			if err := processFile("a.php", strings.NewReader(tt.src), &out); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.wantOut {
+				t.Errorf("stdout:\n got %q\nwant %q", got, tt.wantOut)
+			}
+			if got := errOut.String(); got != tt.wantErr {
+				t.Errorf("stderr:\n got %q\nwant %q", got, tt.wantErr)
+			}
+			if exitCode != tt.wantCode {
+				t.Errorf("got exit code %d, want %d", exitCode, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package phpdoc
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"mibk.dev/phpdoc/internal/token"
+)
+
+// A File represents the doc comments found in a PHP source file.
+type File struct {
+	Comments []*Comment
+}
+
+// A Comment represents a doc comment in a PHP source file. Its
+// source range spans from "/**" to "*/", inclusive.
+type Comment struct {
+	node
+	Block  *Block   // or nil if the comment couldn't be parsed
+	Indent string   // whitespace preceding the comment on its line
+	Decl   DeclKind // kind of the declaration following the comment
+	Name   string   // name of the declaration (without $), or ""
+}
+
+// A DeclKind specifies the kind of a declaration a doc comment belongs
+// to.
+type DeclKind int
+
+const (
+	NoDecl       DeclKind = iota // not followed by a declaration
+	ClassDecl                    // class, interface, trait, or enum
+	FunctionDecl                 // function
+	MethodDecl                   // method
+	PropertyDecl                 // property
+	ConstDecl                    // constant, class constant, or enum case
+)
+
+func (k DeclKind) String() string {
+	switch k {
+	case ClassDecl:
+		return "class"
+	case FunctionDecl:
+		return "function"
+	case MethodDecl:
+		return "method"
+	case PropertyDecl:
+		return "property"
+	case ConstDecl:
+		return "constant"
+	default:
+		return "none"
+	}
+}
+
+// ParseFile finds all doc comments in a PHP source file and parses
+// them. The declarations following the comments are recognized, too.
+//
+// Comments that cannot be parsed are reported in the returned
+// ErrorList, but they're included in the File nevertheless. Unless
+// the AllErrors mode is used, their Block is nil.
+func ParseFile(r io.Reader, mode Mode) (*File, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(File)
+	var errs ErrorList
+	s := &srcScanner{src: src}
+	var (
+		braces       []bool // true for class bodies
+		classPending bool   // the next { opens a class body
+		prev         srcToken
+	)
+LOOP:
+	for tok := s.next(); tok.kind != srcEOF; prev, tok = tok, s.next() {
+		switch tok.kind {
+		case srcDoc:
+			inClass := len(braces) > 0 && braces[len(braces)-1]
+			c := s.parseComment(tok, mode, &errs)
+			c.Decl, c.Name = s.declAfter(inClass)
+			f.Comments = append(f.Comments, c)
+		case srcIdent:
+			switch strings.ToLower(tok.text) {
+			case "class", "interface", "trait":
+				if prev.text != "::" && prev.text != "->" && prev.text != "?->" {
+					classPending = true
+				}
+			case "enum":
+				if next := s.peek(); next.kind == srcIdent {
+					classPending = true
+				}
+			case "__halt_compiler":
+				break LOOP
+			}
+		case srcPunct:
+			switch tok.text {
+			case "{":
+				braces = append(braces, classPending)
+				classPending = false
+			case "}":
+				if len(braces) > 0 {
+					braces = braces[:len(braces)-1]
+				}
+			}
+		}
+	}
+	return f, errs.Err()
+}
+
+// parseComment parses the doc comment tok. Encountered syntax errors
+// are appended to errs.
+func (s *srcScanner) parseComment(tok srcToken, mode Mode, errs *ErrorList) *Comment {
+	c := new(Comment)
+	start := bytes.LastIndexByte(s.src[:tok.off], '\n') + 1
+	if indent := s.src[start:tok.off]; len(bytes.Trim(indent, " \t")) == 0 {
+		c.Indent = string(indent)
+	} else {
+		start = tok.off
+	}
+
+	end := tok.off + len(tok.text)
+	c.setSpan(position(s.pos(tok.off)), position(s.pos(end)))
+	doc, err := parseBlock(s.src, s.pos(start), end, mode)
+	switch err := err.(type) {
+	case nil:
+	case *SyntaxError:
+		*errs = append(*errs, err)
+	case ErrorList:
+		*errs = append(*errs, err...)
+	}
+	c.Block = doc
+	return c
+}
+
+// declAfter recognizes the declaration following the last scanned
+// token, without advancing s. inClass reports whether the token is
+// located directly in a class body.
+func (s srcScanner) declAfter(inClass bool) (kind DeclKind, name string) {
+	attrDepth := 0
+	for {
+		tok := s.next()
+		if attrDepth > 0 {
+			switch tok.text {
+			case "#[", "[":
+				attrDepth++
+			case "]":
+				attrDepth--
+			}
+			if tok.kind != srcEOF {
+				continue
+			}
+		}
+		if tok.kind == srcPunct && tok.text == "#[" {
+			attrDepth++
+			continue
+		}
+		if tok.kind == srcVar {
+			if inClass {
+				return PropertyDecl, tok.text[1:]
+			}
+			return NoDecl, ""
+		}
+		if tok.kind != srcIdent {
+			if inClass && tok.kind == srcPunct && strings.Contains("?|&()", tok.text) {
+				// Part of a property type.
+				continue
+			}
+			return NoDecl, ""
+		}
+
+		switch strings.ToLower(tok.text) {
+		case "public", "protected", "private", "static", "abstract", "final", "readonly", "var":
+			continue
+		case "class", "interface", "trait", "enum":
+			if tok = s.next(); tok.kind == srcIdent {
+				return ClassDecl, tok.text
+			}
+			return NoDecl, ""
+		case "function":
+			if tok = s.next(); tok.text == "&" {
+				tok = s.next()
+			}
+			switch {
+			case tok.kind != srcIdent:
+				// A closure.
+				return NoDecl, ""
+			case inClass:
+				return MethodDecl, tok.text
+			default:
+				return FunctionDecl, tok.text
+			}
+		case "const":
+			// The name is the last identifier before "=",
+			// as the constant might be typed.
+			for tok = s.next(); tok.kind == srcIdent; tok = s.next() {
+				name = tok.text
+			}
+			if tok.text != "=" || name == "" {
+				return NoDecl, ""
+			}
+			return ConstDecl, name
+		case "case":
+			if tok = s.next(); inClass && tok.kind == srcIdent {
+				return ConstDecl, tok.text
+			}
+			return NoDecl, ""
+		}
+		if !inClass {
+			return NoDecl, ""
+		}
+		// Part of a property type.
+	}
+}
+
+type srcTokenKind int
+
+const (
+	srcEOF   srcTokenKind = iota
+	srcIdent              // identifiers, keywords, and (qualified) names
+	srcVar                // variables
+	srcPunct              // operators and punctuation
+	srcDoc                // doc comments
+	srcOther              // literals
+)
+
+type srcToken struct {
+	kind srcTokenKind
+	text string
+	off  int // byte offset
+}
+
+// A srcScanner scans PHP source code well enough to find doc comments
+// and the declarations they belong to. Whitespace, regular comments,
+// and inline HTML are skipped.
+type srcScanner struct {
+	src []byte
+	off int
+	php bool // in PHP mode, as opposed to inline HTML
+
+	lines []int // offsets of line starts; computed lazily
+}
+
+func (s srcScanner) peek() srcToken { return s.next() }
+
+func (s *srcScanner) next() srcToken {
+	for {
+		if !s.php {
+			if !s.skipHTML() {
+				return srcToken{kind: srcEOF, off: s.off}
+			}
+			continue
+		}
+		if s.off >= len(s.src) {
+			return srcToken{kind: srcEOF, off: s.off}
+		}
+
+		start := s.off
+		c := s.src[s.off]
+		switch {
+		case isSpace(c):
+			s.off++
+			continue
+		case c == '#':
+			if s.hasPrefix("#[") {
+				s.off += 2
+				return s.token(srcPunct, start)
+			}
+			s.skipLineComment()
+			continue
+		case s.hasPrefix("//"):
+			s.skipLineComment()
+			continue
+		case s.hasPrefix("/*"):
+			doc := s.hasPrefix("/**") && s.off+3 < len(s.src) && isSpace(s.src[s.off+3])
+			s.skipUntil("*/", 2)
+			if doc {
+				return s.token(srcDoc, start)
+			}
+			continue
+		case s.hasPrefix("?>"):
+			s.off += 2
+			s.php = false
+			return s.token(srcPunct, start)
+		case c == '\'', c == '"', c == '`':
+			s.off++
+			s.skipQuoted(c)
+			return s.token(srcOther, start)
+		case s.hasPrefix("<<<"):
+			s.skipHeredoc()
+			return s.token(srcOther, start)
+		case c == '$' && s.off+1 < len(s.src) && isIdentStart(s.src[s.off+1]):
+			s.off++
+			s.skipIdent()
+			return s.token(srcVar, start)
+		case isIdentStart(c) || c == '\\':
+			for s.off < len(s.src) && (s.src[s.off] == '\\' || isIdentStart(s.src[s.off])) {
+				s.skipIdent()
+				if s.off < len(s.src) && s.src[s.off] == '\\' {
+					s.off++
+				}
+			}
+			return s.token(srcIdent, start)
+		case '0' <= c && c <= '9':
+			s.skipIdent()
+			return s.token(srcOther, start)
+		}
+
+		for _, op := range [...]string{"?->", "::", "->"} {
+			if s.hasPrefix(op) {
+				s.off += len(op)
+				return s.token(srcPunct, start)
+			}
+		}
+		s.off++
+		return s.token(srcPunct, start)
+	}
+}
+
+func (s *srcScanner) token(kind srcTokenKind, start int) srcToken {
+	return srcToken{kind: kind, text: string(s.src[start:s.off]), off: start}
+}
+
+func (s *srcScanner) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(s.src[s.off:], []byte(prefix))
+}
+
+// skipHTML skips inline HTML up to and including the next PHP open
+// tag. It reports whether an open tag was found.
+func (s *srcScanner) skipHTML() bool {
+	for {
+		i := bytes.Index(s.src[s.off:], []byte("<?"))
+		if i < 0 {
+			s.off = len(s.src)
+			return false
+		}
+		s.off += i + 2
+		if s.hasPrefix("=") {
+			s.off++
+		} else if len(s.src)-s.off < 3 || !strings.EqualFold(string(s.src[s.off:s.off+3]), "php") {
+			continue
+		} else {
+			s.off += 3
+		}
+		s.php = true
+		return true
+	}
+}
+
+// skipUntil skips at least min bytes and then everything up to and
+// including the next occurrence of end.
+func (s *srcScanner) skipUntil(end string, min int) {
+	s.off += min
+	if i := bytes.Index(s.src[s.off:], []byte(end)); i >= 0 {
+		s.off += i + len(end)
+	} else {
+		s.off = len(s.src)
+	}
+}
+
+func (s *srcScanner) skipLineComment() {
+	for ; s.off < len(s.src); s.off++ {
+		if s.src[s.off] == '\n' || s.hasPrefix("?>") {
+			return
+		}
+	}
+}
+
+// skipQuoted skips the rest of a string literal delimited by quote,
+// including interpolated expressions in double-quoted strings.
+func (s *srcScanner) skipQuoted(quote byte) {
+	for s.off < len(s.src) {
+		c := s.src[s.off]
+		s.off++
+		switch {
+		case c == '\\':
+			s.off++
+		case c == quote:
+			return
+		case quote != '\'' && c == '{' && s.off < len(s.src) && s.src[s.off] == '$':
+			s.skipInterpolation()
+		}
+	}
+}
+
+// skipInterpolation skips a complex interpolated expression {$…} in
+// a string literal, the opening brace of which has been already read.
+func (s *srcScanner) skipInterpolation() {
+	for depth := 1; depth > 0 && s.off < len(s.src); {
+		c := s.src[s.off]
+		s.off++
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '\'', '"', '`':
+			s.skipQuoted(c)
+		}
+	}
+}
+
+// skipHeredoc skips a heredoc or nowdoc string starting with <<<.
+func (s *srcScanner) skipHeredoc() {
+	s.off += len("<<<")
+	for s.off < len(s.src) && (s.src[s.off] == ' ' || s.src[s.off] == '\t') {
+		s.off++
+	}
+	if s.off < len(s.src) && (s.src[s.off] == '"' || s.src[s.off] == '\'') {
+		s.off++
+	}
+	start := s.off
+	s.skipIdent()
+	label := s.src[start:s.off]
+	if len(label) == 0 {
+		return
+	}
+	for {
+		i := bytes.IndexByte(s.src[s.off:], '\n')
+		if i < 0 {
+			s.off = len(s.src)
+			return
+		}
+		s.off += i + 1
+		line := bytes.TrimLeft(s.src[s.off:], " \t")
+		if bytes.HasPrefix(line, label) && (len(line) == len(label) || !isIdentStart(line[len(label)]) && !isDigit(line[len(label)])) {
+			s.off = len(s.src) - len(line) + len(label)
+			return
+		}
+	}
+}
+
+func (s *srcScanner) skipIdent() {
+	for s.off < len(s.src) && (isIdentStart(s.src[s.off]) || isDigit(s.src[s.off])) {
+		s.off++
+	}
+}
+
+// pos returns the position of the byte offset off.
+func (s *srcScanner) pos(off int) token.Pos {
+	if s.lines == nil {
+		s.lines = append(s.lines, 0)
+		for i, c := range s.src {
+			if c == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	}
+	line := sort.SearchInts(s.lines, off+1) - 1
+	col := utf8.RuneCount(s.src[s.lines[line]:off]) + 1
+	return token.Pos{Line: line + 1, Column: col, Offset: off}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
@@ -0,0 +1,122 @@
+package phpdoc_test
+
+import (
+	"strings"
+	"testing"
+
+	"mibk.dev/phpdoc"
+)
+
+const phpFile = `<html>/** not PHP */
+<?php
+namespace App;
+
+/** @var string */
+const PREFIX = '/** not a comment */';
+
+/**
+ * Model.
+ */
+#[Attribute([1, 2])]
+final class Model extends Base implements \Countable
+{
+	/** @var int */
+	public const int LIMIT = 3;
+
+	/** @var list<string> */
+	private ?array $names = ["{$x["/** no */"]}", <<<EOT
+	  /** nope */
+	  EOT];
+
+	/** Creates a model. */
+	public static function &create(): self
+	{
+		/** @var callable $fn */
+		$fn = function () { return 1; };
+		// /** ignored */ $x = 1;
+		# /** ignored */
+		/* /** ignored */
+		return new static();
+	}
+
+	/**
+	 * Promoted.
+	 */
+	public function __construct(
+		/** @var int */
+		public readonly int $id,
+	) {}
+}
+
+/** @template T */
+enum Suit: string
+{
+	/** Hearts. */
+	case Hearts = 'H';
+}
+
+/** @return void */
+function helper() {}
+?>
+/** HTML again */
+<?= 1 /** @var int */ ?>
+<?php /**@var int doc comment in PHP needs whitespace*/
+/** @param broken<int $x */
+function broken($x) {}
+`
+
+func TestParseFile(t *testing.T) {
+	f, err := phpdoc.ParseFile(strings.NewReader(phpFile), 0)
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+	if got, want := err.Error(), "line:55:23: expecting >, found Var(\"$x\")"; got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+
+	type comment struct {
+		pos    string
+		indent string
+		decl   phpdoc.DeclKind
+		name   string
+	}
+	want := []comment{
+		{"5:1", "", phpdoc.ConstDecl, "PREFIX"},
+		{"8:1", "", phpdoc.ClassDecl, "Model"},
+		{"14:2", "\t", phpdoc.ConstDecl, "LIMIT"},
+		{"17:2", "\t", phpdoc.PropertyDecl, "names"},
+		{"22:2", "\t", phpdoc.MethodDecl, "create"},
+		{"25:3", "\t\t", phpdoc.NoDecl, ""},
+		{"33:2", "\t", phpdoc.MethodDecl, "__construct"},
+		{"37:3", "\t\t", phpdoc.PropertyDecl, "id"},
+		{"42:1", "", phpdoc.ClassDecl, "Suit"},
+		{"45:2", "\t", phpdoc.ConstDecl, "Hearts"},
+		{"49:1", "", phpdoc.FunctionDecl, "helper"},
+		{"53:7", "", phpdoc.NoDecl, ""},
+		{"55:1", "", phpdoc.FunctionDecl, "broken"},
+	}
+	if len(f.Comments) != len(want) {
+		t.Fatalf("got %d comments, want %d", len(f.Comments), len(want))
+	}
+	for i, c := range f.Comments {
+		got := comment{c.Pos().String(), c.Indent, c.Decl, c.Name}
+		if got != want[i] {
+			t.Errorf("comment %d:\n got %+v\nwant %+v", i, got, want[i])
+		}
+		text := phpFile[c.Pos().Offset:c.End().Offset]
+		if !strings.HasPrefix(text, "/**") || !strings.HasSuffix(text, "*/") {
+			t.Errorf("comment %d: unexpected range %q", i, text)
+		}
+		if i == len(want)-1 {
+			if c.Block != nil {
+				t.Errorf("comment %d: got %+v, want nil Block", i, c.Block)
+			}
+			continue
+		}
+		if c.Block == nil {
+			t.Errorf("comment %d: got nil Block", i)
+		} else if c.Block.Pos() != c.Pos() || c.Block.Indent != c.Indent {
+			t.Errorf("comment %d: Block doesn't match the comment", i)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 type Pos struct {
 	Line, Column int
+	Offset       int // byte offset, starting at 0
 }
 
 func (p Pos) String() string {
@@ -17,11 +18,17 @@ func (p Pos) String() string {
 }
 
 type Token struct {
-	Type Type
-	Text string
-	Pos  Pos
+	Type   Type
+	Text   string
+	Pos    Pos
+	EndPos Pos // position immediately after the token
 }
 
+// End returns the position immediately after the token. It's recorded
+// by the scanner, so it's accurate even if Text differs from the
+// source, e.g. if invalid UTF-8 was replaced by U+FFFD.
+func (t Token) End() Pos { return t.EndPos }
+
 func (t Token) String() string {
 	switch {
 	case t.Type == EOF, t.Type == Newline,
@@ -48,6 +55,7 @@ const (
 	Var
 	String
 	Int
+	Float
 	Other
 
 	symbolStart
@@ -72,6 +80,7 @@ const (
 	Or          // |
 	And         // &
 	Assign      // =
+	Not         // !
 	symbolEnd
 
 	keywordStart
@@ -91,24 +100,35 @@ type Scanner struct {
 	err  error
 
 	line, col   int
+	off         int
 	lastLineLen int
+	lastSize    int
 }
 
 func NewScanner(r io.Reader) *Scanner {
+	return NewScannerAt(r, Pos{Line: 1, Column: 1})
+}
+
+// NewScannerAt is like NewScanner, but the positions of the scanned
+// tokens are reported as if the first rune read from r was located at
+// pos.
+func NewScannerAt(r io.Reader, pos Pos) *Scanner {
 	return &Scanner{
 		r:    bufio.NewReader(r),
-		line: 1,
-		col:  1,
+		line: pos.Line,
+		col:  pos.Column,
+		off:  pos.Offset,
 	}
 }
 
 func (s *Scanner) Next() Token {
-	pos := Pos{Line: s.line, Column: s.col}
+	pos := Pos{Line: s.line, Column: s.col, Offset: s.off}
 	tok := s.scanAny()
 	if typ := tok.Type; symbolStart < typ && typ < symbolEnd {
 		tok.Text = typ.String()
 	}
 	tok.Pos = pos
+	tok.EndPos = Pos{Line: s.line, Column: s.col, Offset: s.off}
 	return tok
 }
 
@@ -118,7 +138,7 @@ func (s *Scanner) read() rune {
 	if s.done {
 		return eof
 	}
-	r, _, err := s.r.ReadRune()
+	r, size, err := s.r.ReadRune()
 	if err != nil {
 		if err != io.EOF {
 			s.err = err
@@ -126,6 +146,8 @@ func (s *Scanner) read() rune {
 		s.done = true
 		return eof
 	}
+	s.off += size
+	s.lastSize = size
 	if r == '\n' {
 		s.line++
 		s.lastLineLen, s.col = s.col, 1
@@ -143,6 +165,7 @@ func (s *Scanner) unread() {
 		// UnreadRune returns an error only on invalid use.
 		panic(err)
 	}
+	s.off -= s.lastSize
 	s.col--
 	if s.col == 0 {
 		s.col = s.lastLineLen
@@ -156,6 +179,20 @@ func (s *Scanner) peek() rune {
 	return r
 }
 
+// peekByte returns the n-th byte (counting from 0) that follows the
+// current position, or 0 if there is no such byte. It doesn't consume
+// any input.
+func (s *Scanner) peekByte(n int) byte {
+	if s.done {
+		return 0
+	}
+	b, _ := s.r.Peek(n + 1)
+	if len(b) <= n {
+		return 0
+	}
+	return b[n]
+}
+
 func (s *Scanner) scanAny() Token {
 	switch r := s.read(); r {
 	case eof:
@@ -219,12 +256,21 @@ func (s *Scanner) scanAny() Token {
 		return Token{Type: And}
 	case '=':
 		return Token{Type: Assign}
+	case '!':
+		return Token{Type: Not}
 	case '\n':
 		return Token{Type: Newline, Text: string(r)}
 	case ' ', '\t':
 		return s.scanWhitespace(r)
 	case '\'':
 		return s.scanSingleQuoted()
+	case '"':
+		return s.scanDoubleQuoted()
+	case '-':
+		if isDigit(s.peek()) {
+			return s.scanNumber(r)
+		}
+		return Token{Type: Ident, Text: "-" + s.scanIdent()}
 	default:
 		if isDigit(r) {
 			return s.scanNumber(r)
@@ -328,19 +374,106 @@ func (s *Scanner) scanSingleQuoted() Token {
 	}
 }
 
-func (s *Scanner) scanNumber(r rune) Token {
+func (s *Scanner) scanDoubleQuoted() Token {
+	var b strings.Builder
+	b.WriteByte('"')
+	for {
+		r := s.read()
+		switch r {
+		case '\n', '*', eof:
+			// TODO: A string might include ‘*’, though.
+			s.unread()
+			return Token{Type: Other, Text: b.String()}
+		}
+		b.WriteRune(r)
+		switch r {
+		case '\\':
+			switch r := s.peek(); r {
+			case '\n', '*', eof:
+			default:
+				b.WriteRune(s.read())
+			}
+		case '"':
+			return Token{Type: String, Text: b.String()}
+		}
+	}
+}
+
+// scanNumber scans a PHP integer or float literal, optionally preceded
+// by a minus sign. Digits may be separated by underscores (e.g. 1_000).
+func (s *Scanner) scanNumber(init rune) Token {
 	var b strings.Builder
-	b.WriteRune(r)
-	for isDigit(s.peek()) {
+	b.WriteRune(init)
+	if init == '-' {
+		init = s.read()
+		b.WriteRune(init)
+	}
+
+	if init == '0' {
+		var isBase func(byte) bool
+		switch s.peekByte(0) {
+		case 'x', 'X':
+			isBase = isHex
+		case 'o', 'O':
+			isBase = isOctal
+		case 'b', 'B':
+			isBase = isBinary
+		}
+		if isBase != nil && isBase(s.peekByte(1)) {
+			b.WriteRune(s.read())
+			s.scanDigits(&b, isBase)
+			return Token{Type: Int, Text: b.String()}
+		}
+	}
+
+	typ := Int
+	s.scanDigits(&b, isDecimal)
+	if s.peekByte(0) == '.' && isDecimal(s.peekByte(1)) {
+		typ = Float
+		b.WriteRune(s.read())
+		s.scanDigits(&b, isDecimal)
+	}
+	if c := s.peekByte(0); c == 'e' || c == 'E' {
+		n := 1
+		if c := s.peekByte(1); c == '+' || c == '-' {
+			n++
+		}
+		if isDecimal(s.peekByte(n)) {
+			typ = Float
+			for ; n > 0; n-- {
+				b.WriteRune(s.read())
+			}
+			s.scanDigits(&b, isDecimal)
+		}
+	}
+	return Token{Type: typ, Text: b.String()}
+}
+
+// scanDigits scans digits for which isBase returns true, separated
+// by single underscores.
+func (s *Scanner) scanDigits(b *strings.Builder, isBase func(byte) bool) {
+	for {
+		c := s.peekByte(0)
+		if c == '_' && isBase(s.peekByte(1)) {
+			b.WriteRune(s.read())
+		} else if !isBase(c) {
+			return
+		}
 		b.WriteRune(s.read())
 	}
-	return Token{Type: Int, Text: b.String()}
 }
 
 func isDigit(r rune) bool {
 	return '0' <= r && r <= '9'
 }
 
+func isDecimal(c byte) bool { return '0' <= c && c <= '9' }
+func isOctal(c byte) bool   { return '0' <= c && c <= '7' }
+func isBinary(c byte) bool  { return c == '0' || c == '1' }
+func isHex(c byte) bool {
+	return isDecimal(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
 func (s *Scanner) scanWhitespace(init rune) Token {
 	var b strings.Builder
 	b.WriteRune(init)
@@ -4,16 +4,28 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
 
 	"mibk.dev/phpdoc/internal/token"
 )
 
-func pos(posStr string) token.Pos {
-	var pos token.Pos
-	fmt.Sscanf(posStr, "%d:%d", &pos.Line, &pos.Column)
-	return pos
+// posIn returns a function that converts a line:column position
+// to a token.Pos, computing the byte offset within input.
+func posIn(input string) func(posStr string) token.Pos {
+	return func(posStr string) token.Pos {
+		var pos token.Pos
+		fmt.Sscanf(posStr, "%d:%d", &pos.Line, &pos.Column)
+		lines := strings.SplitAfter(input, "\n")
+		for _, l := range lines[:pos.Line-1] {
+			pos.Offset += len(l)
+		}
+		for _, r := range []rune(lines[pos.Line-1])[:pos.Column-1] {
+			pos.Offset += utf8.RuneLen(r)
+		}
+		return pos
+	}
 }
 
 func TestScanner(t *testing.T) {
@@ -25,6 +37,7 @@ func TestScanner(t *testing.T) {
 */`
 
 	sc := token.NewScanner(strings.NewReader(input))
+	pos := posIn(input)
 
 	var got []token.Token
 	for {
@@ -34,65 +47,74 @@ func TestScanner(t *testing.T) {
 			break
 		}
 	}
+	for i, tok := range got[:len(got)-1] {
+		if next := got[i+1].Pos; tok.End() != next {
+			t.Errorf("%v ends at %v (offset %d), want %v (offset %d)",
+				tok, tok.End(), tok.End().Offset, next, next.Offset)
+		}
+	}
+	for i := range got {
+		got[i].EndPos = token.Pos{}
+	}
 
 	want := []token.Token{
-		{token.OpenDoc, "/**", pos("1:1")},
-		{token.Newline, "\n", pos("1:4")},
-		{token.Whitespace, "\t", pos("2:1")},
-		{token.Tag, "@param", pos("2:2")},
-		{token.Whitespace, " ", pos("2:8")},
-		{token.Lparen, "(", pos("2:9")},
-		{token.Backslash, "\\", pos("2:10")},
-		{token.Ident, "Traversable", pos("2:11")},
-		{token.And, "&", pos("2:22")},
-		{token.Backslash, "\\", pos("2:23")},
-		{token.Ident, "Countable", pos("2:24")},
-		{token.Rparen, ")", pos("2:33")},
-		{token.Or, "|", pos("2:34")},
-		{token.Array, "array", pos("2:35")},
-		{token.Lbrace, "{", pos("2:40")},
-		{token.Int, "11", pos("2:41")},
-		{token.Whitespace, " ", pos("2:43")},
-		{token.Colon, ":", pos("2:44")},
-		{token.Ident, "int", pos("2:45")},
-		{token.Rbrace, "}", pos("2:48")},
-		{token.Whitespace, " ", pos("2:49")},
-		{token.Var, "$map", pos("2:50")},
-		{token.Newline, "\n", pos("2:54")},
-		{token.Whitespace, "\t", pos("3:1")},
-		{token.Tag, "@param", pos("3:2")},
-		{token.Whitespace, " ", pos("3:8")},
-		{token.Ident, "int", pos("3:9")},
-		{token.Or, "|", pos("3:12")},
-		{token.Ident, "null", pos("3:13")},
-		{token.Whitespace, " ", pos("3:17")},
-		{token.Ellipsis, "...", pos("3:18")},
-		{token.Var, "$_0_žluťoučký_9", pos("3:21")},
-		{token.Newline, "\n", pos("3:36")},
-		{token.Whitespace, "\t", pos("4:1")},
-		{token.Asterisk, "*", pos("4:2")},
-		{token.Whitespace, " ", pos("4:3")},
-		{token.Tag, "@return", pos("4:4")},
-		{token.Whitespace, " ", pos("4:11")},
-		{token.Ident, "string", pos("4:12")},
-		{token.Lbrack, "[", pos("4:18")},
-		{token.Rbrack, "]", pos("4:19")},
-		{token.Or, "|", pos("4:20")},
-		{token.Array, "array", pos("4:21")},
-		{token.Lt, "<", pos("4:26")},
-		{token.Ident, "string", pos("4:27")},
-		{token.Comma, ",", pos("4:33")},
-		{token.Whitespace, " ", pos("4:34")},
-		{token.Qmark, "?", pos("4:35")},
-		{token.Ident, "string", pos("4:36")},
-		{token.Gt, ">", pos("4:42")},
-		{token.Newline, "\n", pos("4:43")},
-		{token.Whitespace, "\t", pos("5:1")},
-		{token.Other, "@test123 ", pos("5:2")},
-		{token.Tag, "@testCase", pos("5:11")},
-		{token.Newline, "\n", pos("5:20")},
-		{token.CloseDoc, `*/`, pos("6:1")},
-		{token.EOF, "", pos("6:3")},
+		{Type: token.OpenDoc, Text: "/**", Pos: pos("1:1")},
+		{Type: token.Newline, Text: "\n", Pos: pos("1:4")},
+		{Type: token.Whitespace, Text: "\t", Pos: pos("2:1")},
+		{Type: token.Tag, Text: "@param", Pos: pos("2:2")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("2:8")},
+		{Type: token.Lparen, Text: "(", Pos: pos("2:9")},
+		{Type: token.Backslash, Text: "\\", Pos: pos("2:10")},
+		{Type: token.Ident, Text: "Traversable", Pos: pos("2:11")},
+		{Type: token.And, Text: "&", Pos: pos("2:22")},
+		{Type: token.Backslash, Text: "\\", Pos: pos("2:23")},
+		{Type: token.Ident, Text: "Countable", Pos: pos("2:24")},
+		{Type: token.Rparen, Text: ")", Pos: pos("2:33")},
+		{Type: token.Or, Text: "|", Pos: pos("2:34")},
+		{Type: token.Array, Text: "array", Pos: pos("2:35")},
+		{Type: token.Lbrace, Text: "{", Pos: pos("2:40")},
+		{Type: token.Int, Text: "11", Pos: pos("2:41")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("2:43")},
+		{Type: token.Colon, Text: ":", Pos: pos("2:44")},
+		{Type: token.Ident, Text: "int", Pos: pos("2:45")},
+		{Type: token.Rbrace, Text: "}", Pos: pos("2:48")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("2:49")},
+		{Type: token.Var, Text: "$map", Pos: pos("2:50")},
+		{Type: token.Newline, Text: "\n", Pos: pos("2:54")},
+		{Type: token.Whitespace, Text: "\t", Pos: pos("3:1")},
+		{Type: token.Tag, Text: "@param", Pos: pos("3:2")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("3:8")},
+		{Type: token.Ident, Text: "int", Pos: pos("3:9")},
+		{Type: token.Or, Text: "|", Pos: pos("3:12")},
+		{Type: token.Ident, Text: "null", Pos: pos("3:13")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("3:17")},
+		{Type: token.Ellipsis, Text: "...", Pos: pos("3:18")},
+		{Type: token.Var, Text: "$_0_žluťoučký_9", Pos: pos("3:21")},
+		{Type: token.Newline, Text: "\n", Pos: pos("3:36")},
+		{Type: token.Whitespace, Text: "\t", Pos: pos("4:1")},
+		{Type: token.Asterisk, Text: "*", Pos: pos("4:2")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("4:3")},
+		{Type: token.Tag, Text: "@return", Pos: pos("4:4")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("4:11")},
+		{Type: token.Ident, Text: "string", Pos: pos("4:12")},
+		{Type: token.Lbrack, Text: "[", Pos: pos("4:18")},
+		{Type: token.Rbrack, Text: "]", Pos: pos("4:19")},
+		{Type: token.Or, Text: "|", Pos: pos("4:20")},
+		{Type: token.Array, Text: "array", Pos: pos("4:21")},
+		{Type: token.Lt, Text: "<", Pos: pos("4:26")},
+		{Type: token.Ident, Text: "string", Pos: pos("4:27")},
+		{Type: token.Comma, Text: ",", Pos: pos("4:33")},
+		{Type: token.Whitespace, Text: " ", Pos: pos("4:34")},
+		{Type: token.Qmark, Text: "?", Pos: pos("4:35")},
+		{Type: token.Ident, Text: "string", Pos: pos("4:36")},
+		{Type: token.Gt, Text: ">", Pos: pos("4:42")},
+		{Type: token.Newline, Text: "\n", Pos: pos("4:43")},
+		{Type: token.Whitespace, Text: "\t", Pos: pos("5:1")},
+		{Type: token.Other, Text: "@test123 ", Pos: pos("5:2")},
+		{Type: token.Tag, Text: "@testCase", Pos: pos("5:11")},
+		{Type: token.Newline, Text: "\n", Pos: pos("5:20")},
+		{Type: token.CloseDoc, Text: `*/`, Pos: pos("6:1")},
+		{Type: token.EOF, Text: "", Pos: pos("6:3")},
 	}
 	if err := sc.Err(); err != nil {
 		t.Fatalf("unexpected err: %v", err)
@@ -121,3 +143,80 @@ type badReader struct{}
 func (badReader) Read(p []byte) (n int, err error) {
 	return 0, fmt.Errorf("i'm fine")
 }
+
+func TestScanNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token.Token
+	}{
+		{"-12", []token.Token{{Type: token.Int, Text: "-12"}}},
+		{"-x", []token.Token{{Type: token.Ident, Text: "-x"}}},
+		{"- 1", []token.Token{
+			{Type: token.Ident, Text: "-"},
+			{Type: token.Whitespace, Text: " "},
+			{Type: token.Int, Text: "1"},
+		}},
+		{"a-1", []token.Token{{Type: token.Ident, Text: "a-1"}}},
+		{"1_000|0x1F|0b1_0|0o17|017", []token.Token{
+			{Type: token.Int, Text: "1_000"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Int, Text: "0x1F"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Int, Text: "0b1_0"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Int, Text: "0o17"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Int, Text: "017"},
+		}},
+		{"1.5|-0.5e-3|1E3", []token.Token{
+			{Type: token.Float, Text: "1.5"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Float, Text: "-0.5e-3"},
+			{Type: token.Or, Text: "|"},
+			{Type: token.Float, Text: "1E3"},
+		}},
+		{"1..2", []token.Token{
+			{Type: token.Int, Text: "1"},
+			{Type: token.Other, Text: "..2"},
+		}},
+		{"1_", []token.Token{
+			{Type: token.Int, Text: "1"},
+			{Type: token.Ident, Text: "_"},
+		}},
+		{`"a\"b"`, []token.Token{{Type: token.String, Text: `"a\"b"`}}},
+	}
+	for _, tt := range tests {
+		sc := token.NewScanner(strings.NewReader(tt.input))
+		var got []token.Token
+		for {
+			tok := sc.Next()
+			if tok.Type == token.EOF {
+				break
+			}
+			tok.Pos, tok.EndPos = token.Pos{}, token.Pos{}
+			got = append(got, tok)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%q: tokens don't match (-got +want)\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestInvalidUTF8Offsets(t *testing.T) {
+	const input = "0\x8b \x8bx\xff"
+	sc := token.NewScanner(strings.NewReader(input))
+	end := token.Pos{Line: 1, Column: 1}
+	for {
+		tok := sc.Next()
+		if tok.Pos != end {
+			t.Errorf("%v starts at offset %d, want %d", tok, tok.Pos.Offset, end.Offset)
+		}
+		end = tok.End()
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	if end.Offset != len(input) {
+		t.Errorf("input ends at offset %d, want %d", end.Offset, len(input))
+	}
+}
@@ -17,41 +17,43 @@ func _() {
 	_ = x[Var-6]
 	_ = x[String-7]
 	_ = x[Int-8]
-	_ = x[Other-9]
-	_ = x[symbolStart-10]
-	_ = x[OpenDoc-11]
-	_ = x[CloseDoc-12]
-	_ = x[Asterisk-13]
-	_ = x[Backslash-14]
-	_ = x[Qmark-15]
-	_ = x[Lparen-16]
-	_ = x[Rparen-17]
-	_ = x[Lbrack-18]
-	_ = x[Rbrack-19]
-	_ = x[Lbrace-20]
-	_ = x[Rbrace-21]
-	_ = x[Lt-22]
-	_ = x[Gt-23]
-	_ = x[Comma-24]
-	_ = x[Colon-25]
-	_ = x[DoubleColon-26]
-	_ = x[Ellipsis-27]
-	_ = x[Or-28]
-	_ = x[And-29]
-	_ = x[Assign-30]
-	_ = x[symbolEnd-31]
-	_ = x[keywordStart-32]
-	_ = x[This-33]
-	_ = x[Array-34]
-	_ = x[Object-35]
-	_ = x[Callable-36]
-	_ = x[Static-37]
-	_ = x[keywordEnd-38]
+	_ = x[Float-9]
+	_ = x[Other-10]
+	_ = x[symbolStart-11]
+	_ = x[OpenDoc-12]
+	_ = x[CloseDoc-13]
+	_ = x[Asterisk-14]
+	_ = x[Backslash-15]
+	_ = x[Qmark-16]
+	_ = x[Lparen-17]
+	_ = x[Rparen-18]
+	_ = x[Lbrack-19]
+	_ = x[Rbrack-20]
+	_ = x[Lbrace-21]
+	_ = x[Rbrace-22]
+	_ = x[Lt-23]
+	_ = x[Gt-24]
+	_ = x[Comma-25]
+	_ = x[Colon-26]
+	_ = x[DoubleColon-27]
+	_ = x[Ellipsis-28]
+	_ = x[Or-29]
+	_ = x[And-30]
+	_ = x[Assign-31]
+	_ = x[Not-32]
+	_ = x[symbolEnd-33]
+	_ = x[keywordStart-34]
+	_ = x[This-35]
+	_ = x[Array-36]
+	_ = x[Object-37]
+	_ = x[Callable-38]
+	_ = x[Static-39]
+	_ = x[keywordEnd-40]
 }
 
-const _Type_name = "IllegalEOF\\nWhitespaceIdentTagVarStringIntOthersymbolStart/***/*\\?()[]{}<>,:::...|&=symbolEndkeywordStart$thisarrayobjectcallablestatickeywordEnd"
+const _Type_name = "IllegalEOF\\nWhitespaceIdentTagVarStringIntFloatOthersymbolStart/***/*\\?()[]{}<>,:::...|&=!symbolEndkeywordStart$thisarrayobjectcallablestatickeywordEnd"
 
-var _Type_index = [...]uint8{0, 7, 10, 12, 22, 27, 30, 33, 39, 42, 47, 58, 61, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73, 74, 75, 76, 78, 81, 82, 83, 84, 93, 105, 110, 115, 121, 129, 135, 145}
+var _Type_index = [...]uint8{0, 7, 10, 12, 22, 27, 30, 33, 39, 42, 47, 52, 63, 66, 68, 69, 70, 71, 72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 83, 86, 87, 88, 89, 90, 99, 111, 116, 121, 127, 135, 141, 151}
 
 func (i Type) String() string {
 	if i >= Type(len(_Type_index)-1) {
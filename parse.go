@@ -1,9 +1,13 @@
 package phpdoc
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"mibk.dev/phpdoc/internal/token"
 	"mibk.dev/phpdoc/phptype"
@@ -12,6 +16,7 @@ import (
 // SyntaxError records an error and the position it occured on.
 type SyntaxError struct {
 	Line, Column int
+	Offset       int // byte offset, starting at 0
 	Err          error
 }
 
@@ -19,20 +24,115 @@ func (e *SyntaxError) Error() string {
 	return fmt.Sprintf("line:%d:%d: %v", e.Line, e.Column, e.Err)
 }
 
+// An ErrorList is a list of syntax errors.
+type ErrorList []*SyntaxError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns an error equivalent to this error list. If the list is
+// empty, Err returns nil.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// A Mode value is a set of flags (or 0). They control the parser
+// behavior.
+type Mode uint
+
+const (
+	// AllErrors makes the parser report all errors, instead of
+	// stopping at the first one. Tags that cannot be parsed are
+	// replaced by BadTag nodes, and the partial Block is returned
+	// along with an ErrorList.
+	AllErrors Mode = 1 << iota
+)
+
 type parser struct {
 	scan *token.Scanner
+	src  []byte
+	mode Mode
 
-	err  error
-	tok  token.Token
-	prev token.Token
-	alt  *token.Token // on backup
+	err    error
+	errs   ErrorList    // in the AllErrors mode
+	errTok *token.Token // token the current error occured on
+	tok    token.Token
+	prev   token.Token
+	alt    *token.Token // on backup
+
+	lastEnd token.Pos // end of the last consumed token
+	prevEnd token.Pos // lastEnd before the last consumed token
+
+	tagPos   phptype.Pos   // position of the tag being parsed
+	descCont []phptype.Pos // continuation lines of the last description
 }
 
 // Parse parses a single PHPDoc comment.
 func Parse(r io.Reader) (*Block, error) {
-	p := &parser{scan: token.NewScanner(r)}
+	return ParseWithMode(r, 0)
+}
+
+// ParseWithMode is like Parse, but the mode flags control the parser
+// behavior.
+func ParseWithMode(r io.Reader, mode Mode) (*Block, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlock(src, token.Pos{Line: 1, Column: 1}, len(src), mode)
+}
+
+// ParseType parses a single PHP type, e.g. array<int, string>. Any
+// text following the type, other than whitespace, is reported as an
+// error. The returned error, if any, is a *SyntaxError.
+func ParseType(r io.Reader) (phptype.Type, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{scan: token.NewScanner(bytes.NewReader(src)), src: src}
+	p.next() // init
+	for p.got(token.Newline) {
+	}
+	typ := p.parseType()
+	for p.got(token.Newline) {
+	}
+	if p.tok.Type != token.EOF {
+		p.errorf("unexpected %v after type", p.tok)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return typ, nil
+}
+
+// ParseTypeString is like ParseType, but it parses the type from s.
+func ParseTypeString(s string) (phptype.Type, error) {
+	return ParseType(strings.NewReader(s))
+}
+
+// parseBlock parses the comment located in src[pos.Offset:end].
+func parseBlock(src []byte, pos token.Pos, end int, mode Mode) (*Block, error) {
+	sc := token.NewScannerAt(bytes.NewReader(src[pos.Offset:end]), pos)
+	p := &parser{scan: sc, src: src, mode: mode}
 	p.next0() // init
 	doc := p.parseDoc()
+	if mode&AllErrors != 0 {
+		if p.err != nil {
+			p.errs = append(p.errs, p.err.(*SyntaxError))
+		}
+		return doc, p.errs.Err()
+	}
 	if p.err != nil {
 		return nil, p.err
 	}
@@ -46,12 +146,18 @@ func (p *parser) backup() {
 	p.alt = new(token.Token)
 	*p.alt = p.tok
 	p.tok = p.prev
+	p.lastEnd = p.prevEnd
 }
 
 func (p *parser) next0() {
 	if p.tok.Type == token.EOF {
 		return
 	}
+	switch p.tok.Type {
+	case token.Whitespace, token.Newline:
+	default:
+		p.prevEnd, p.lastEnd = p.lastEnd, p.tok.End()
+	}
 	if p.alt != nil {
 		p.tok, p.alt = *p.alt, nil
 		return
@@ -94,14 +200,66 @@ func (p *parser) consume(types ...token.Type) {
 }
 
 func (p *parser) errorf(format string, args ...interface{}) {
+	p.errorAt(p.pos(), format, args...)
+}
+
+// errorAt is like errorf, but it reports the error at pos.
+func (p *parser) errorAt(pos phptype.Pos, format string, args ...interface{}) {
 	if p.err == nil {
+		if p.errTok == nil {
+			tok := p.tok
+			p.errTok = &tok
+		}
 		p.tok.Type = token.EOF
 		se := &SyntaxError{Err: fmt.Errorf(format, args...)}
-		se.Line, se.Column = p.tok.Pos.Line, p.tok.Pos.Column
+		se.Line, se.Column = pos.Line, pos.Column
+		se.Offset = pos.Offset
 		p.err = se
 	}
 }
 
+// pos returns the position of the current token.
+func (p *parser) pos() phptype.Pos { return position(p.tok.Pos) }
+
+// end returns the position immediately after the last consumed
+// token. If no token has been consumed since pos, pos is returned.
+func (p *parser) end(pos phptype.Pos) phptype.Pos {
+	if p.lastEnd.Offset <= pos.Offset {
+		return pos
+	}
+	return position(p.lastEnd)
+}
+
+func position(pos token.Pos) phptype.Pos {
+	return phptype.Pos{Line: pos.Line, Column: pos.Column, Offset: pos.Offset}
+}
+
+// A spanner is a node whose source range can be set by the parser.
+type spanner interface {
+	setSpan(pos, end phptype.Pos)
+}
+
+// A describer is a line whose description can be located in the
+// source.
+type describer interface {
+	descStarts() []phptype.Pos
+	setDescStarts(pos []phptype.Pos)
+}
+
+// advance returns the position immediately after s, assuming s is
+// located at pos.
+func advance(pos phptype.Pos, s string) phptype.Pos {
+	for _, r := range s {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 0
+		}
+		pos.Column++
+	}
+	pos.Offset += len(s)
+	return pos
+}
+
 // The syntax comments roughly follow the notation as defined at
 // https://golang.org/ref/spec#Notation.
 
@@ -116,19 +274,50 @@ func (p *parser) parseDoc() *Block {
 		doc.Indent = p.tok.Text
 		p.next0()
 	}
+	pos := p.pos()
 	p.expect(token.OpenDoc)
 	if !p.got(token.Newline) {
 		doc.PreferOneline = true
 	}
 	doc.Lines = p.parseLines()
 	p.expect(token.CloseDoc)
+	doc.setSpan(pos, p.end(pos))
 	return doc
 }
 
+// sync records the current error and skips the rest of the tag the
+// error occured in, including its continuation lines. It's only used
+// in the AllErrors mode.
+func (p *parser) sync() {
+	p.errs = append(p.errs, p.err.(*SyntaxError))
+	p.err = nil
+	p.tok, p.errTok = *p.errTok, nil
+	for {
+		switch p.tok.Type {
+		case token.Newline:
+			if p.continuation() == 0 {
+				return
+			}
+		case token.CloseDoc, token.EOF:
+			return
+		}
+		p.next0()
+	}
+}
+
 func (p *parser) parseLines() []Line {
 	var lines []Line
 	for p.tok.Type != token.CloseDoc {
-		lines = append(lines, p.parseLine())
+		line := p.parseLine()
+		if p.err != nil && p.mode&AllErrors != 0 {
+			p.sync()
+			bad := new(BadTag)
+			pos, end := line.Pos(), p.end(line.Pos())
+			bad.Text = strings.TrimSpace(string(p.src[pos.Offset:end.Offset]))
+			bad.setSpan(pos, end)
+			line = bad
+		}
+		lines = append(lines, line)
 		if !p.got(token.Newline) {
 			break
 		}
@@ -140,6 +329,7 @@ func (p *parser) parseLines() []Line {
 // TextLine = Desc .
 func (p *parser) parseLine() Line {
 	p.consume(token.Whitespace)
+	pos := p.pos()
 	var b strings.Builder
 	if p.tok.Type == token.Asterisk {
 		b.WriteString(p.tok.Text)
@@ -149,11 +339,49 @@ func (p *parser) parseLine() Line {
 		b.WriteString(p.tok.Text)
 		p.next0()
 	}
+	var line Line
 	if p.tok.Type == token.Tag {
-		return p.parseTag()
+		pos = p.pos()
+		p.tagPos = pos
+		line = p.parseTag()
 	} else {
-		return &TextLine{Value: p.parseDesc(&b)}
+		line = &TextLine{Value: p.parseDesc(&b)}
+	}
+	line.(spanner).setSpan(pos, p.end(pos))
+	if desc := lineDesc(line); desc != "" {
+		p.locateDesc(line, pos, desc)
 	}
+	return line
+}
+
+// locateDesc records the positions of the lines of the description
+// desc of line, which starts at pos.
+func (p *parser) locateDesc(line Line, pos phptype.Pos, desc string) {
+	n := strings.Count(desc, "\n") + 1
+	cont := p.descCont
+	if n > len(cont)+1 {
+		return
+	}
+	starts := make([]phptype.Pos, 0, n)
+	if n > len(cont) {
+		// The first line is the trailing text of the tag line.
+		first := desc
+		if i := strings.IndexByte(desc, '\n'); i >= 0 {
+			first = desc[:i]
+		}
+		nl := bytes.IndexByte(p.src[pos.Offset:], '\n')
+		if nl < 0 {
+			nl = len(p.src) - pos.Offset
+		}
+		src := string(p.src[pos.Offset : pos.Offset+nl])
+		i := strings.LastIndex(src, first)
+		if i < 0 {
+			return
+		}
+		starts = append(starts, advance(pos, src[:i]))
+	}
+	starts = append(starts, cont[len(cont)-(n-len(starts)):]...)
+	line.(describer).setDescStarts(starts)
 }
 
 // Tag = ParamTag |
@@ -166,58 +394,108 @@ func (p *parser) parseLine() Line {
 //	ImplementsTag |
 //	TemplateTag |
 //	TypeDefTag |
+//	ImportTypeTag |
+//	MixinTag |
+//	ParamOutTag |
+//	AssertTag |
+//	SelfOutTag |
+//	FlagTag |
+//	DeprecatedTag |
+//	SinceTag |
+//	VersionTag |
+//	SeeTag |
+//	LinkTag |
 //	OtherTag .
 func (p *parser) parseTag() Tag {
 	name := p.tok.Text
 	p.expect(token.Tag)
 
-	switch name {
-	case "@param":
-		return p.parseParamTag()
-	case "@return":
-		return p.parseReturnTag()
-	case "@property", "@property-read", "@property-write":
-		return p.parsePropertyTag(name)
-	case "@method":
-		return p.parseMethodTag()
-	case "@var":
-		return p.parseVarTag()
-	case "@throws":
-		return p.parseThrowsTag()
-	case "@extends":
-		return p.parseExtendsTag()
-	case "@implements":
-		return p.parseImplementsTag()
-	case "@uses":
-		return p.parseUsesTag()
-	case "@template":
-		return p.parseTemplateTag()
-	case "@phpstan-type":
-		return p.parseTypeDefTag()
-	default:
-		return p.parseOtherTag(name[1:])
+	vendor, base := splitVendor(name[1:])
+	switch base {
+	case "param":
+		return p.parseParamTag(vendor)
+	case "return":
+		return p.parseReturnTag(vendor)
+	case "property", "property-read", "property-write":
+		return p.parsePropertyTag(vendor, base)
+	case "method":
+		return p.parseMethodTag(vendor)
+	case "var":
+		return p.parseVarTag(vendor)
+	case "throws":
+		return p.parseThrowsTag(vendor)
+	case "extends":
+		return p.parseExtendsTag(vendor)
+	case "implements":
+		return p.parseImplementsTag(vendor)
+	case "uses":
+		return p.parseUsesTag(vendor)
+	case "template", "template-covariant", "template-contravariant":
+		return p.parseTemplateTag(vendor, base)
+	case "type":
+		if vendor != "" {
+			return p.parseTypeDefTag(vendor)
+		}
+	case "import-type":
+		if vendor != "" {
+			return p.parseImportTypeTag(vendor)
+		}
+	case "mixin":
+		return p.parseMixinTag(vendor)
+	case "param-out":
+		return p.parseParamOutTag(vendor)
+	case "assert", "assert-if-true", "assert-if-false":
+		if vendor != "" {
+			return p.parseAssertTag(vendor, base)
+		}
+	case "self-out":
+		if vendor != "" {
+			return p.parseSelfOutTag(vendor)
+		}
+	case "internal":
+		if vendor != "" {
+			// @psalm-internal takes a namespace, so it doesn't
+			// merely flag the element as @internal does.
+			return p.parseOtherTag(name[1:])
+		}
 	}
+	if flagTags[strings.ToLower(base)] {
+		return p.parseFlagTag(vendor, base)
+	}
+	switch name[1:] {
+	case "deprecated":
+		return p.parseDeprecatedTag()
+	case "since":
+		return p.parseSinceTag()
+	case "version":
+		return p.parseVersionTag()
+	case "see":
+		return p.parseSeeTag()
+	case "link":
+		return p.parseLinkTag()
+	}
+	return p.parseOtherTag(name[1:])
 }
 
-// ParamTag = "@param" Param [ Desc ] .
-func (p *parser) parseParamTag() *ParamTag {
-	tag := new(ParamTag)
+// ParamTag = "@" [ vendor "-" ] "param" Param [ Desc ] .
+func (p *parser) parseParamTag(vendor string) *ParamTag {
+	tag := &ParamTag{Vendor: vendor}
 	tag.Param = p.parseParam(true)
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// ReturnTag = "@return" PHPType [ Desc ] .
-func (p *parser) parseReturnTag() *ReturnTag {
-	tag := new(ReturnTag)
+// ReturnTag = "@" [ vendor "-" ] "return" PHPType [ Desc ] .
+func (p *parser) parseReturnTag(vendor string) *ReturnTag {
+	tag := &ReturnTag{Vendor: vendor}
 	tag.Type = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// PropertyTag = ( "@property" | "@property-read" | "@property-write" ) PHPType varname [ Desc ] .
-func (p *parser) parsePropertyTag(name string) *PropertyTag {
-	tag := new(PropertyTag)
+// PropertyTag = "@" [ vendor "-" ] ( "property" | "property-read" | "property-write" ) PHPType varname [ Desc ] .
+func (p *parser) parsePropertyTag(vendor, name string) *PropertyTag {
+	tag := &PropertyTag{Vendor: vendor}
 	tag.Type = p.parseType()
 	tag.Var = strings.TrimPrefix(p.tok.Text, "$")
 	p.expect(token.Var)
@@ -232,9 +510,9 @@ func (p *parser) parsePropertyTag(name string) *PropertyTag {
 	return tag
 }
 
-// MethodTag = "@method" [ PHPType ] ident "(" [ ParamList [ "," ] ] ")" [ Desc ] .
-func (p *parser) parseMethodTag() *MethodTag {
-	tag := new(MethodTag)
+// MethodTag = "@" [ vendor "-" ] "method" [ PHPType ] ident "(" [ ParamList [ "," ] ] ")" [ Desc ] .
+func (p *parser) parseMethodTag(vendor string) *MethodTag {
+	tag := &MethodTag{Vendor: vendor}
 	tag.Static = p.got(token.Static)
 	tag.Result = p.parseType()
 	tag.Name = p.tok.Text
@@ -258,9 +536,9 @@ func (p *parser) parseMethodTag() *MethodTag {
 	return tag
 }
 
-// VarTag = "@var" PHPType [ varname ] [ Desc ] .
-func (p *parser) parseVarTag() *VarTag {
-	tag := new(VarTag)
+// VarTag = "@" [ vendor "-" ] "var" PHPType [ varname ] [ Desc ] .
+func (p *parser) parseVarTag(vendor string) *VarTag {
+	tag := &VarTag{Vendor: vendor}
 	tag.Type = p.parseType()
 	if p.tok.Type == token.Var {
 		tag.Var = p.tok.Text[1:]
@@ -270,61 +548,266 @@ func (p *parser) parseVarTag() *VarTag {
 	return tag
 }
 
-// ThrowsTag = "@throws" PHPType [ Desc ] .
-func (p *parser) parseThrowsTag() *ThrowsTag {
-	tag := new(ThrowsTag)
+// ThrowsTag = "@" [ vendor "-" ] "throws" PHPType [ Desc ] .
+func (p *parser) parseThrowsTag(vendor string) *ThrowsTag {
+	tag := &ThrowsTag{Vendor: vendor}
 	tag.Class = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// ExtendsTag = "@extends" PHPType [ Desc ] .
-func (p *parser) parseExtendsTag() *ExtendsTag {
-	tag := new(ExtendsTag)
+// ExtendsTag = "@" [ vendor "-" ] "extends" PHPType [ Desc ] .
+func (p *parser) parseExtendsTag(vendor string) *ExtendsTag {
+	tag := &ExtendsTag{Vendor: vendor}
 	tag.Class = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// ImplementsTag = "@implements" PHPType [ Desc ] .
-func (p *parser) parseImplementsTag() *ImplementsTag {
-	tag := new(ImplementsTag)
+// ImplementsTag = "@" [ vendor "-" ] "implements" PHPType [ Desc ] .
+func (p *parser) parseImplementsTag(vendor string) *ImplementsTag {
+	tag := &ImplementsTag{Vendor: vendor}
 	tag.Interface = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// UsesTag = "@uses" PHPType [ Desc ] .
-func (p *parser) parseUsesTag() *UsesTag {
-	tag := new(UsesTag)
+// UsesTag = "@" [ vendor "-" ] "uses" PHPType [ Desc ] .
+func (p *parser) parseUsesTag(vendor string) *UsesTag {
+	tag := &UsesTag{Vendor: vendor}
 	tag.Trait = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// TemplateTag = "@template" ident [ ( "of | "as" ) PHPType ] [ Desc ] .
-func (p *parser) parseTemplateTag() *TemplateTag {
-	tag := new(TemplateTag)
+// MixinTag = "@" [ vendor "-" ] "mixin" PHPType [ Desc ] .
+func (p *parser) parseMixinTag(vendor string) *MixinTag {
+	tag := &MixinTag{Vendor: vendor}
+	tag.Class = p.parseType()
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// ParamOutTag = "@" [ vendor "-" ] "param-out" PHPType varname [ Desc ] .
+func (p *parser) parseParamOutTag(vendor string) *ParamOutTag {
+	tag := &ParamOutTag{Vendor: vendor}
+	tag.Type = p.parseType()
+	tag.Var = strings.TrimPrefix(p.tok.Text, "$")
+	p.expect(token.Var)
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// AssertTag = "@" vendor "-" ( "assert" | "assert-if-true" | "assert-if-false" ) [ "!" ] [ "=" ] PHPType varname [ Desc ] .
+func (p *parser) parseAssertTag(vendor, name string) *AssertTag {
+	tag := &AssertTag{Vendor: vendor}
+	switch name {
+	case "assert-if-true":
+		tag.IfTrue = true
+	case "assert-if-false":
+		tag.IfFalse = true
+	}
+	tag.Negated = p.got(token.Not)
+	tag.Equal = p.got(token.Assign)
+	tag.Type = p.parseType()
+	tag.Var = strings.TrimPrefix(p.tok.Text, "$")
+	p.expect(token.Var)
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// SelfOutTag = "@" vendor "-" "self-out" PHPType [ Desc ] .
+func (p *parser) parseSelfOutTag(vendor string) *SelfOutTag {
+	tag := &SelfOutTag{Vendor: vendor}
+	tag.Type = p.parseType()
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// vendors lists the prefixes of tool-specific tags, e.g. @phpstan-param.
+var vendors = []string{"phpstan", "psalm", "phan"}
+
+// splitVendor splits the tag name into the vendor prefix, if any, and
+// the base name.
+func splitVendor(name string) (vendor, base string) {
+	for _, v := range vendors {
+		if strings.HasPrefix(name, v+"-") {
+			return v, name[len(v)+1:]
+		}
+	}
+	return "", name
+}
+
+// TemplateTag = "@" [ vendor "-" ] "template" [ "-covariant" | "-contravariant" ] ident [ ( "of" | "as" ) PHPType ] [ "=" PHPType ] [ Desc ] .
+func (p *parser) parseTemplateTag(vendor, name string) *TemplateTag {
+	tag := &TemplateTag{Vendor: vendor}
+	switch name {
+	case "template-covariant":
+		tag.Variance = phptype.Covariant
+	case "template-contravariant":
+		tag.Variance = phptype.Contravariant
+	}
 	tag.Param = p.tok.Text
 	p.expect(token.Ident)
 	if p.tok.Type == token.Ident && (p.tok.Text == "of" || p.tok.Text == "as") {
 		p.next()
 		tag.Bound = p.parseType()
 	}
+	if p.got(token.Assign) {
+		tag.Default = p.parseType()
+	}
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
-// TypeDefTag = "@phpstan-type" ident PHPType [ Desc ] .
-func (p *parser) parseTypeDefTag() *TypeDefTag {
-	tag := new(TypeDefTag)
+// TypeDefTag = "@" vendor "-type" ident [ "=" ] PHPType [ Desc ] .
+func (p *parser) parseTypeDefTag(vendor string) *TypeDefTag {
+	tag := &TypeDefTag{Vendor: vendor}
 	tag.Name = p.tok.Text
 	p.expect(token.Ident)
+	p.got(token.Assign)
 	tag.Type = p.parseType()
 	tag.Desc = p.parseDesc(nil)
 	return tag
 }
 
+// ImportTypeTag = "@" vendor "-import-type" ident "from" NamedType [ "as" ident ] [ Desc ] .
+func (p *parser) parseImportTypeTag(vendor string) *ImportTypeTag {
+	tag := &ImportTypeTag{Vendor: vendor}
+	tag.Name = p.tok.Text
+	p.expect(token.Ident)
+	if p.tok.Type != token.Ident || p.tok.Text != "from" {
+		p.errorf("unexpected %v, expecting from", p.tok)
+	}
+	p.next()
+	from, ok := p.parseNamedType()
+	if !ok {
+		p.errorf("expecting class name, found %v", p.tok)
+	}
+	tag.From = from
+	if p.tok.Type == token.Ident && p.tok.Text == "as" {
+		p.next()
+		tag.Alias = p.tok.Text
+		p.expect(token.Ident)
+	}
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// flagTags lists the lowercased names of the tags parsed as FlagTag.
+var flagTags = map[string]bool{
+	"abstract":           true,
+	"api":                true,
+	"final":              true,
+	"immutable":          true,
+	"impure":             true,
+	"inheritdoc":         true,
+	"internal":           true,
+	"no-named-arguments": true,
+	"pure":               true,
+	"readonly":           true,
+}
+
+// FlagTag = "@" [ vendor "-" ] flagname [ Desc ] .
+func (p *parser) parseFlagTag(vendor, name string) *FlagTag {
+	tag := &FlagTag{Vendor: vendor, Name: name}
+	tag.Desc = p.parseDesc(nil)
+	return tag
+}
+
+// DeprecatedTag = "@deprecated" [ version ] [ Desc ] .
+func (p *parser) parseDeprecatedTag() *DeprecatedTag {
+	tag := new(DeprecatedTag)
+	tag.Version, tag.Desc = p.parseVersionDesc()
+	return tag
+}
+
+// SinceTag = "@since" [ version ] [ Desc ] .
+func (p *parser) parseSinceTag() *SinceTag {
+	tag := new(SinceTag)
+	tag.Version, tag.Desc = p.parseVersionDesc()
+	return tag
+}
+
+// VersionTag = "@version" [ version ] [ Desc ] .
+func (p *parser) parseVersionTag() *VersionTag {
+	tag := new(VersionTag)
+	tag.Version, tag.Desc = p.parseVersionDesc()
+	return tag
+}
+
+// parseVersionDesc parses the description, splitting off the leading
+// version, if any.
+func (p *parser) parseVersionDesc() (version, desc string) {
+	desc = p.parseDesc(nil)
+	if v, rest := cutWord(desc); isVersion(v) {
+		return v, rest
+	}
+	return "", desc
+}
+
+// isVersion reports whether s looks like a version: dot-separated
+// numbers, optionally prefixed by "v" and followed by a suffix
+// (e.g. 3.0, v2, 1.2.0-beta). A single number without the "v" prefix
+// is not a version, as in "@deprecated 2 reasons below".
+func isVersion(s string) bool {
+	v := strings.TrimPrefix(s, "v")
+	prefixed := len(v) < len(s)
+	for nums := 1; ; nums++ {
+		i := 0
+		for i < len(v) && isDigit(v[i]) {
+			i++
+		}
+		if i == 0 {
+			return false
+		}
+		v = v[i:]
+		if len(v) < 2 || v[0] != '.' || !isDigit(v[1]) {
+			if nums == 1 && !prefixed {
+				return false
+			}
+			break
+		}
+		v = v[1:]
+	}
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case isDigit(c), 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case c == '.' || c == '-' || c == '+':
+			if i == len(v)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SeeTag = "@see" ( fqsen | url ) [ Desc ] .
+func (p *parser) parseSeeTag() *SeeTag {
+	tag := new(SeeTag)
+	tag.Ref, tag.Desc = cutWord(p.parseDesc(nil))
+	return tag
+}
+
+// LinkTag = "@link" uri [ Desc ] .
+func (p *parser) parseLinkTag() *LinkTag {
+	tag := new(LinkTag)
+	tag.URI, tag.Desc = cutWord(p.parseDesc(nil))
+	return tag
+}
+
+// cutWord slices s around the first run of spaces. If the word ends
+// the line, the rest begins with the line break.
+func cutWord(s string) (word, rest string) {
+	i := strings.IndexAny(s, " \t\n")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimLeft(s[i:], " \t")
+}
+
 // OtherTag = tagname [ Desc ] .
 func (p *parser) parseOtherTag(name string) *OtherTag {
 	tag := &OtherTag{Name: name}
@@ -353,6 +836,7 @@ func (p *parser) parseUnionType(init phptype.Type) phptype.Type {
 		typ := p.parseAtomicType()
 		union.Types = append(union.Types, typ)
 	}
+	union.StartPos, union.EndPos = init.Pos(), p.end(init.Pos())
 	return union
 }
 
@@ -369,14 +853,16 @@ func (p *parser) parseIntersectType(init phptype.Type) phptype.Type {
 		}
 		intersect.Types = append(intersect.Types, typ)
 	}
+	intersect.StartPos, intersect.EndPos = init.Pos(), p.end(init.Pos())
 	return intersect
 }
 
-// AtomicType   = ParenType | ThisType | BasicType | GenericType | NullableType | ArrayType .
-// ThisType     = "$this" .
-// BasicType    = NamedType | CallableType | ArrayShapeType | ObjectShapeType | ConstFetch | LitType .
-// ArrayType    = AtomicType "[" "]" .
-// NullableType = "?" ( BasicType | GenericType ) .
+// AtomicType       = ParenType | ThisType | BasicType | GenericType | NullableType | ArrayType | OffsetAccessType .
+// ThisType         = "$this" .
+// BasicType        = NamedType | CallableType | ArrayShapeType | ObjectShapeType | ConstFetch | LitType .
+// ArrayType        = AtomicType "[" "]" .
+// OffsetAccessType = AtomicType "[" PHPType "]" .
+// NullableType     = "?" ( BasicType | GenericType | IntRange | Operator ) .
 func (p *parser) parseAtomicType() phptype.Type {
 	typ, ok := p.tryParseAtomicType()
 	if !ok {
@@ -387,22 +873,30 @@ func (p *parser) parseAtomicType() phptype.Type {
 
 func (p *parser) tryParseAtomicType() (_ phptype.Type, ok bool) {
 	var typ phptype.Type
+	pos := p.pos()
 	if p.got(token.Lparen) {
-		typ = p.parseParenType()
+		typ = p.parseParenType(pos)
 	} else if p.got(token.This) {
-		typ = new(phptype.This)
+		this := new(phptype.This)
+		this.StartPos, this.EndPos = pos, p.end(pos)
+		typ = this
 	} else {
 		nullable := p.got(token.Qmark)
 		if p.got(token.Array) {
-			typ = p.parseArrayShapeType()
+			typ = p.parseArrayShapeType(position(p.prev.Pos), phptype.PlainArray)
 		} else if p.got(token.Object) {
-			typ = p.parseObjectShapeType()
+			typ = p.parseObjectShapeType(p.prev.Pos)
 		} else if p.got(token.Callable) {
-			typ = p.parseCallableType()
-		} else if typ, ok = p.parseNamedType(); !ok {
-			if typ, ok = p.parseLitType(); !ok {
-				return nil, false
+			typ = p.parseCallableType(position(p.prev.Pos), p.prev.Text)
+		} else if typ, ok = p.parseNamedType(); ok {
+			if kind, ok := shapeKind(typ); ok && p.tok.Type == token.Lbrace {
+				typ = p.parseArrayShapeType(typ.Pos(), kind)
+			} else if isClosure(typ) && (p.tok.Type == token.Lparen ||
+				p.tok.Type == token.Lt && p.signatureFollows()) {
+				typ = p.parseCallableType(typ.Pos(), typ.String())
 			}
+		} else if typ, ok = p.parseLitType(); !ok {
+			return nil, false
 		}
 		if ok && p.got(token.DoubleColon) {
 			if nullable {
@@ -424,18 +918,43 @@ func (p *parser) tryParseAtomicType() (_ phptype.Type, ok bool) {
 			if p.got(token.Asterisk) {
 				p.errorf("invalid position of *, did you mean to write %s*?", cf.Name)
 			}
+			cf.StartPos, cf.EndPos = typ.Pos(), p.end(typ.Pos())
 			typ = cf
 		} else if p.got(token.Lt) {
-			// TODO: Forbid generic params for arrays with a shape?
-			typ = p.parseGenericType(typ)
+			if isInt(typ) {
+				typ = p.parseIntRange(typ.Pos())
+			} else if op, ok := typeOperator(typ); ok {
+				typ = p.parseOperator(op, typ.Pos())
+			} else {
+				// TODO: Forbid generic params for arrays with a shape?
+				typ = p.parseGenericType(typ)
+			}
 		}
 		if nullable {
-			typ = &phptype.Nullable{Type: typ}
+			n := &phptype.Nullable{Type: typ}
+			n.StartPos, n.EndPos = pos, p.end(pos)
+			typ = n
 		}
 	}
-	for p.got(token.Lbrack) {
+	for p.tok.Type == token.Lbrack {
+		// A spaced "[" may only start "[]", so that in e.g.
+		// "string [optional]" it's left for the description.
+		spaced := p.tok.Pos.Offset != p.lastEnd.Offset
+		p.next()
+		if p.got(token.Rbrack) {
+			arr := &phptype.Array{Elem: typ}
+			arr.StartPos, arr.EndPos = pos, p.end(pos)
+			typ = arr
+			continue
+		}
+		if spaced {
+			p.backup()
+			break
+		}
+		acc := &phptype.OffsetAccess{Type: typ, Offset: p.parseType()}
 		p.expect(token.Rbrack)
-		typ = &phptype.Array{Elem: typ}
+		acc.StartPos, acc.EndPos = pos, p.end(pos)
+		typ = acc
 	}
 	if p.got(token.DoubleColon) {
 		p.errorf("unexpected %v", token.DoubleColon)
@@ -444,39 +963,149 @@ func (p *parser) tryParseAtomicType() (_ phptype.Type, ok bool) {
 	return typ, true
 }
 
-// ParenType = "(" PHPType ")" .
-func (p *parser) parseParenType() phptype.Type {
-	typ := new(phptype.Paren)
-	typ.Type = p.parseType()
+// ParenType       = "(" ( PHPType | ConditionalType ) ")" .
+// ConditionalType = ( varname | PHPType ) "is" [ "not" ] PHPType "?" PHPType ":" PHPType .
+func (p *parser) parseParenType(pos phptype.Pos) phptype.Type {
+	var param string
+	var subject phptype.Type
+	if v := strings.TrimPrefix(p.tok.Text, "$"); p.got(token.Var) {
+		param = v
+	} else {
+		subject = p.parseType()
+	}
+	if param != "" || p.tok.Type == token.Ident && p.tok.Text == "is" {
+		return p.parseConditionalType(pos, param, subject)
+	}
+	typ := &phptype.Paren{Type: subject}
 	p.expect(token.Rparen)
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
 	return typ
 }
 
-// CallableType  = callable [ FuncSignature ] .
-// FuncSignature = "(" [ ParamList [ "," ] ] ")" [ ":" PHPType ] .
-func (p *parser) parseCallableType() phptype.Type {
-	typ := new(phptype.Callable)
-	if !p.got(token.Lparen) {
-		return typ
+func (p *parser) parseConditionalType(pos phptype.Pos, param string, subject phptype.Type) phptype.Type {
+	typ := &phptype.Conditional{Param: param, Subject: subject}
+	if p.tok.Type != token.Ident || p.tok.Text != "is" {
+		p.errorf("unexpected %v, expecting is", p.tok)
 	}
-	typ.Params = p.parseParamList()
-	if p.got(token.Colon) {
-		typ.Result = p.parseType()
+	p.next()
+	if p.tok.Type == token.Ident && p.tok.Text == "not" {
+		typ.Negated = true
+		p.next()
 	}
+	typ.Target = p.parseType()
+	p.expect(token.Qmark)
+	typ.If = p.parseType()
+	p.expect(token.Colon)
+	typ.Else = p.parseType()
+	p.expect(token.Rparen)
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
 	return typ
 }
 
-// ParamList = Param [ "=" LitType ] { "," Param [ "=" LitType ] } .
+// isClosure reports whether t names a type that may be followed by
+// a callable signature, i.e. Closure (qualified or not), pure-callable,
+// or pure-Closure. Like with callable, the signature may be separated
+// by whitespace. Template params make a signature only if a parameter
+// list follows them; otherwise, e.g. Closure<T> is parsed as a generic
+// type.
+func isClosure(t phptype.Type) bool {
+	id, ok := t.(*phptype.Named)
+	if !ok {
+		return false
+	}
+	switch name := strings.ToLower(id.Parts[len(id.Parts)-1]); {
+	case name == "closure":
+		return true
+	case id.Global || len(id.Parts) > 1:
+		return false
+	default:
+		return name == "pure-callable" || name == "pure-closure"
+	}
+}
+
+// signatureFollows reports whether the angle brackets starting at the
+// current token are followed by "(", i.e. whether they enclose template
+// params of a callable signature.
+func (p *parser) signatureFollows() bool {
+	src := p.src
+	depth := 0
+	for i := p.tok.Pos.Offset; i < len(src); i++ {
+		switch src[i] {
+		case '<':
+			depth++
+		case '>':
+			if depth--; depth == 0 {
+				for i++; i < len(src) && (src[i] == ' ' || src[i] == '\t'); i++ {
+				}
+				return i < len(src) && src[i] == '('
+			}
+		case '\n':
+			return false
+		}
+	}
+	return false
+}
+
+// CallableType   = ( callable | CallableName ) [ TemplateParams ] [ FuncSignature ] .
+// CallableName   = Closure | "\\" Closure | pure-callable | pure-Closure .
+// TemplateParams = "<" TemplateParam { "," TemplateParam } [ "," ] ">" .
+// TemplateParam  = ident [ of PHPType ] .
+// FuncSignature  = "(" [ ParamList [ "," ] ] ")" [ ":" PHPType ] .
+func (p *parser) parseCallableType(pos phptype.Pos, name string) phptype.Type {
+	typ := &phptype.Callable{Name: name}
+	if p.tok.Type == token.Lt && p.signatureFollows() {
+		p.next()
+		typ.TemplateParams = p.parseTemplateParams()
+		if p.tok.Type != token.Lparen {
+			p.expect(token.Lparen)
+		}
+	}
+	if p.got(token.Lparen) {
+		typ.Params = p.parseParamList()
+		if p.got(token.Colon) {
+			typ.Result = p.parseType()
+		}
+	}
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
+	return typ
+}
+
+func (p *parser) parseTemplateParams() []*phptype.TemplateParam {
+	var params []*phptype.TemplateParam
+	for {
+		if len(params) > 0 && p.tok.Type == token.Gt {
+			// Allow trailing comma.
+			break
+		}
+		tp := &phptype.TemplateParam{Name: p.tok.Text}
+		pos := p.pos()
+		p.expect(token.Ident)
+		if p.tok.Type == token.Ident && p.tok.Text == "of" {
+			p.next()
+			tp.Bound = p.parseType()
+		}
+		tp.StartPos, tp.EndPos = pos, p.end(pos)
+		params = append(params, tp)
+		if !p.got(token.Comma) {
+			break
+		}
+	}
+	p.expect(token.Gt)
+	return params
+}
+
+// ParamList = Param [ "=" [ LitType ] ] { "," Param [ "=" [ LitType ] ] } .
 func (p *parser) parseParamList() []*phptype.Param {
 	var params []*phptype.Param
 	for !p.got(token.Rparen) && !p.got(token.EOF) {
 		par := p.parseParam(false)
 		if p.got(token.Assign) {
-			lit, ok := p.parseLitType()
-			if !ok {
-				p.errorf("expecting literal value, found %v", p.tok)
+			if lit, ok := p.parseLitType(); ok {
+				par.Default = lit
+			} else {
+				par.Optional = true
 			}
-			par.Default = lit
+			par.EndPos = p.end(par.StartPos)
 		}
 		params = append(params, par)
 		if p.got(token.Rparen) {
@@ -490,6 +1119,7 @@ func (p *parser) parseParamList() []*phptype.Param {
 // Param = PHPType [ [ "&" ] [ "..." ] varname ] .
 func (p *parser) parseParam(needVar bool) *phptype.Param {
 	par := new(phptype.Param)
+	pos := p.pos()
 	par.Type = p.parseType()
 	if p.got(token.And) {
 		needVar = true
@@ -504,20 +1134,44 @@ func (p *parser) parseParam(needVar bool) *phptype.Param {
 	} else if needVar {
 		p.expect(token.Var)
 	}
+	par.StartPos, par.EndPos = pos, p.end(pos)
 	return par
 }
 
-// ArrayShapeType = array [ ArrayShape ] .
-// ArrayShape     = "{" KeyType { "," KeyType } [ "," ] "}" .
+func shapeKind(t phptype.Type) (kind phptype.ArrayKind, ok bool) {
+	id, ok := t.(*phptype.Named)
+	if !ok || id.Global || len(id.Parts) != 1 {
+		return 0, false
+	}
+	switch id.Parts[0] {
+	case "list":
+		return phptype.List, true
+	case "non-empty-array":
+		return phptype.NonEmptyArray, true
+	case "non-empty-list":
+		return phptype.NonEmptyList, true
+	}
+	return 0, false
+}
+
+// ArrayShapeType = ( array | list | non-empty-array | non-empty-list ) [ ArrayShape ] .
+// ArrayShape     = "{" [ KeyType { "," KeyType } [ "," ] ] [ Unsealed [ "," ] ] "}" .
 // KeyType        = [ ArrayKey [ "?" ] ":" ] PHPType .
 // ArrayKey       = string | ident | decimal .
-func (p *parser) parseArrayShapeType() phptype.Type {
-	typ := new(phptype.ArrayShape)
+// Unsealed       = "..." [ "<" PHPType [ "," PHPType ] ">" ] .
+func (p *parser) parseArrayShapeType(pos phptype.Pos, kind phptype.ArrayKind) phptype.Type {
+	typ := &phptype.ArrayShape{Kind: kind}
 	if p.got(token.Lbrace) {
 	Elems:
 		for {
 			elem := new(phptype.ArrayElem)
+			elemPos := p.pos()
 			switch p.tok.Type {
+			case token.Ellipsis:
+				p.next()
+				p.parseUnsealed(typ)
+				p.got(token.Comma)
+				break Elems
 			case token.String, token.Ident, token.Int:
 				elem.Key = p.tok.Text
 				p.next()
@@ -527,10 +1181,11 @@ func (p *parser) parseArrayShapeType() phptype.Type {
 					p.backup()
 				}
 			case token.Rbrace:
-				// Allow trailing comma.
-				if len(typ.Elems) > 0 {
-					break Elems
+				// Allow trailing comma and empty shapes.
+				if typ.Elems == nil {
+					typ.Elems = []*phptype.ArrayElem{}
 				}
+				break Elems
 			}
 			had := p.tok
 			elem.Type = p.parseType()
@@ -538,6 +1193,7 @@ func (p *parser) parseArrayShapeType() phptype.Type {
 				p.err = nil
 				p.errorf("expecting array shape key, or value; found %v", had)
 			}
+			elem.StartPos, elem.EndPos = elemPos, p.end(elemPos)
 			typ.Elems = append(typ.Elems, elem)
 			if !p.got(token.Comma) {
 				break
@@ -545,21 +1201,36 @@ func (p *parser) parseArrayShapeType() phptype.Type {
 		}
 		p.expect(token.Rbrace)
 	}
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
 	return typ
 }
 
+func (p *parser) parseUnsealed(typ *phptype.ArrayShape) {
+	typ.Unsealed = true
+	if !p.got(token.Lt) {
+		return
+	}
+	typ.ExtraValue = p.parseType()
+	if p.got(token.Comma) {
+		typ.ExtraKey, typ.ExtraValue = typ.ExtraValue, p.parseType()
+	}
+	p.expect(token.Gt)
+}
+
 // ObjectShapeType = object [ ObjectShape ] .
 // ObjectShape     = "{" KeyType { "," KeyType } [ "," ] "}" .
 // ObjectKeyType   = ObjectKey [ "?" ] ":" PHPType .
-// ObjectKey       = ident .
-func (p *parser) parseObjectShapeType() phptype.Type {
+// ObjectKey       = ident | string .
+func (p *parser) parseObjectShapeType(tokPos token.Pos) phptype.Type {
+	pos := position(tokPos)
 	typ := new(phptype.ObjectShape)
 	if p.got(token.Lbrace) {
 	Elems:
 		for {
 			elem := new(phptype.ObjectElem)
+			elemPos := p.pos()
 			switch p.tok.Type {
-			case token.Ident:
+			case token.Ident, token.String:
 				elem.Key = p.tok.Text
 				p.next()
 			case token.Rbrace:
@@ -575,6 +1246,7 @@ func (p *parser) parseObjectShapeType() phptype.Type {
 			elem.Optional = p.got(token.Qmark)
 			p.expect(token.Colon)
 			elem.Type = p.parseType()
+			elem.StartPos, elem.EndPos = elemPos, p.end(elemPos)
 			typ.Elems = append(typ.Elems, elem)
 			if !p.got(token.Comma) {
 				break
@@ -582,36 +1254,141 @@ func (p *parser) parseObjectShapeType() phptype.Type {
 		}
 		p.expect(token.Rbrace)
 	}
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
 	return typ
 }
 
-// GenericType = BasicType "<" PHPType { "," PHPType } [ "," ] ">" .
+// GenericType = BasicType "<" TypeParam { "," TypeParam } [ "," ] ">" .
 func (p *parser) parseGenericType(base phptype.Type) phptype.Type {
-	var params []phptype.Type
+	var params []*phptype.TypeParam
 	for {
 		if len(params) > 0 && p.tok.Type == token.Gt {
 			// Allow trailing comma.
 			break
 		}
-		t := p.parseType()
-		params = append(params, t)
+		params = append(params, p.parseTypeParam())
 		if !p.got(token.Comma) {
 			break
 		}
 	}
 	p.expect(token.Gt)
-	return &phptype.Generic{Base: base, TypeParams: params}
+	typ := &phptype.Generic{Base: base, TypeParams: params}
+	typ.StartPos, typ.EndPos = base.Pos(), p.end(base.Pos())
+	return typ
+}
+
+// TypeParam = "*" | [ covariant | contravariant ] PHPType .
+func (p *parser) parseTypeParam() *phptype.TypeParam {
+	tp := new(phptype.TypeParam)
+	pos := p.pos()
+	if p.got(token.Asterisk) {
+		tp.Wildcard = true
+		tp.StartPos, tp.EndPos = pos, p.end(pos)
+		return tp
+	}
+	if p.tok.Type == token.Ident {
+		switch p.tok.Text {
+		case "covariant":
+			tp.Variance = phptype.Covariant
+		case "contravariant":
+			tp.Variance = phptype.Contravariant
+		}
+		if tp.Variance != phptype.Invariant {
+			p.next()
+			if p.tok.Type == token.Gt || p.tok.Type == token.Comma {
+				// Just a class named covariant, or contravariant.
+				p.backup()
+				tp.Variance = phptype.Invariant
+			}
+		}
+	}
+	tp.Type = p.parseType()
+	tp.StartPos, tp.EndPos = pos, p.end(pos)
+	return tp
+}
+
+func isInt(t phptype.Type) bool {
+	id, ok := t.(*phptype.Named)
+	return ok && !id.Global && len(id.Parts) == 1 && strings.EqualFold(id.Parts[0], "int")
+}
+
+// IntRange = int "<" ( integer | min ) "," ( integer | max ) [ "," ] ">" .
+func (p *parser) parseIntRange(pos phptype.Pos) phptype.Type {
+	typ := new(phptype.IntRange)
+	typ.Min = p.parseIntBound("min")
+	p.expect(token.Comma)
+	typ.Max = p.parseIntBound("max")
+	if typ.Min != nil && typ.Max != nil && *typ.Min > *typ.Max {
+		p.errorAt(pos, "invalid int range: min %d is greater than max %d", *typ.Min, *typ.Max)
+	}
+	p.got(token.Comma)
+	p.expect(token.Gt)
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
+	return typ
+}
+
+// parseIntBound parses a bound of an int range. It returns nil for the
+// unbounded keyword kw.
+func (p *parser) parseIntBound(kw string) *int64 {
+	switch p.tok.Type {
+	case token.Int:
+		v, err := strconv.ParseInt(p.tok.Text, 0, 64)
+		if err != nil {
+			p.errorf("invalid int range bound %s", p.tok.Text)
+			return nil
+		}
+		p.next()
+		return &v
+	case token.Ident:
+		if p.tok.Text == kw {
+			p.next()
+			return nil
+		}
+	}
+	p.errorf("unexpected %v, expecting integer or %s", p.tok, kw)
+	return nil
+}
+
+func typeOperator(t phptype.Type) (op phptype.OpKind, ok bool) {
+	id, ok := t.(*phptype.Named)
+	if !ok || id.Global || len(id.Parts) != 1 {
+		return 0, false
+	}
+	return phptype.LookupOp(id.Parts[0])
+}
+
+// Operator = ( key-of | value-of | int-mask | int-mask-of | new ) "<" PHPType { "," PHPType } [ "," ] ">" .
+func (p *parser) parseOperator(op phptype.OpKind, pos phptype.Pos) phptype.Type {
+	typ := &phptype.Operator{Op: op}
+	for {
+		if len(typ.Args) > 0 && p.tok.Type == token.Gt {
+			// Allow trailing comma.
+			break
+		}
+		typ.Args = append(typ.Args, p.parseType())
+		if !p.got(token.Comma) {
+			break
+		}
+	}
+	if op != phptype.IntMask && len(typ.Args) > 1 {
+		p.errorf("%v expects exactly one type argument", op)
+	}
+	p.expect(token.Gt)
+	typ.StartPos, typ.EndPos = pos, p.end(pos)
+	return typ
 }
 
 // NamedType = static | [ "\\" ] ident { "\\" ident } .
 func (p *parser) parseNamedType() (_ *phptype.Named, ok bool) {
 	id := new(phptype.Named)
+	pos := p.pos()
 	switch p.tok.Type {
 	default:
 		return nil, false
 	case token.Static:
 		id.Parts = append(id.Parts, p.tok.Text)
 		p.next()
+		id.StartPos, id.EndPos = pos, p.end(pos)
 		return id, true
 	case token.Backslash, token.Ident:
 	}
@@ -625,35 +1402,220 @@ func (p *parser) parseNamedType() (_ *phptype.Named, ok bool) {
 			break
 		}
 	}
+	id.StartPos, id.EndPos = pos, p.end(pos)
 	return id, true
 }
 
-// LitType = string | decimal | ident .
+// LitType = string | int | float | ident .
 func (p *parser) parseLitType() (_ *phptype.Literal, ok bool) {
-	lit := new(phptype.Literal)
+	lit := &phptype.Literal{Value: p.tok.Text}
+	pos := p.pos()
 	switch p.tok.Type {
-	case token.String, token.Int, token.Ident:
-		lit.Value = p.tok.Text
-		p.next()
-		return lit, true
+	case token.String:
+		lit.Kind = phptype.StringLit
+		if _, err := phptype.Unquote(lit.Value); err != nil {
+			p.errorf("invalid string literal %s", lit.Value)
+			return nil, false
+		}
+	case token.Int:
+		lit.Kind = phptype.IntLit
+	case token.Float:
+		lit.Kind = phptype.FloatLit
+	case token.Ident:
+		switch strings.ToLower(lit.Value) {
+		case "true", "false":
+			lit.Kind = phptype.BoolLit
+		case "null":
+			lit.Kind = phptype.NullLit
+		default:
+			lit.Kind = phptype.ConstLit
+		}
 	default:
 		return nil, false
 	}
+	p.next()
+	lit.StartPos, lit.EndPos = pos, p.end(pos)
+	return lit, true
 }
 
 // Desc = { any } .
 func (p *parser) parseDesc(b *strings.Builder) string {
+	// Only tag descriptions continue on the following lines.
+	tag := b == nil
 	if b == nil {
 		b = new(strings.Builder)
 	}
+	p.descCont = p.descCont[:0]
 LOOP:
 	for {
 		switch p.tok.Type {
-		case token.Newline, token.CloseDoc, token.EOF:
+		case token.Newline:
+			if tag && p.continueDesc(b) {
+				continue
+			}
+			break LOOP
+		case token.CloseDoc, token.EOF:
 			break LOOP
 		}
 		b.WriteString(p.tok.Text)
 		p.next0()
 	}
-	return strings.TrimSpace(b.String())
+	desc := strings.TrimSpace(b.String())
+	if len(p.descCont) > strings.Count(desc, "\n") {
+		// The description starts on the line following the tag.
+		desc = "\n" + desc
+	}
+	return desc
+}
+
+// continueDesc reports whether the line following the current newline
+// continues the description in b, i.e. whether it's indented more than
+// the tag. If so, the line prefix is consumed, and the line break,
+// along with the indentation relative to the first continuation line,
+// is written to b.
+func (p *parser) continueDesc(b *strings.Builder) bool {
+	desc := strings.TrimRight(b.String(), " \t")
+	col := p.continuation()
+	if col == 0 {
+		return false
+	}
+	extra := 0
+	if len(p.descCont) > 0 {
+		if extra = col - p.descCont[0].Column; extra < 0 {
+			extra = 0
+		}
+	}
+
+	p.next0()
+	p.consume(token.Whitespace, token.Asterisk, token.Whitespace)
+	pos := p.pos()
+	pos.Column -= extra
+	pos.Offset -= extra
+	p.descCont = append(p.descCont, pos)
+
+	b.Reset()
+	if desc != "" {
+		b.WriteString(desc)
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Repeat(" ", extra))
+	return true
+}
+
+// continuation returns the column of the text on the line following
+// the current newline, if the line continues the current tag, i.e. if
+// it's indented more than the tag. Otherwise, it returns 0.
+func (p *parser) continuation() int {
+	src := p.src
+	start := p.tok.Pos.Offset + 1
+	i := start
+	skip := func() {
+		for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+			i++
+		}
+	}
+	skip()
+	if bytes.HasPrefix(src[i:], []byte("*/")) {
+		return 0
+	}
+	if i < len(src) && src[i] == '*' {
+		i++
+		skip()
+	}
+	if i == len(src) || src[i] == '\n' || src[i] == '@' || bytes.HasPrefix(src[i:], []byte("*/")) {
+		return 0
+	}
+	if col := 1 + utf8.RuneCount(src[start:i]); col > p.tagPos.Column {
+		return col
+	}
+	return 0
+}
+
+// lineDesc returns the description of line as printed, i.e. without
+// the leading asterisk of text lines.
+func lineDesc(line Line) string {
+	switch l := line.(type) {
+	case *TextLine:
+		if l.Value == "*" {
+			return ""
+		}
+		return strings.TrimPrefix(l.Value, "* ")
+	case Tag:
+		return l.desc()
+	}
+	return ""
+}
+
+// ParseDescription splits the description of line, which is either
+// a *TextLine or a Tag, into plain text and inline tags, such as
+// {@link https://example.com label}. Malformed inline tags are kept as
+// plain text. The positions are only valid if line was created by the
+// parser.
+//
+// InlineTag = "{@" tagname [ whitespace text ] "}" .
+func ParseDescription(line Line) *Description {
+	s := lineDesc(line)
+	starts := line.(describer).descStarts()
+	// pos returns the position of s[i].
+	pos := func(i int) phptype.Pos {
+		k := strings.Count(s[:i], "\n")
+		if k >= len(starts) {
+			return phptype.Pos{}
+		}
+		return advance(starts[k], s[strings.LastIndexByte(s[:i], '\n')+1:i])
+	}
+	d := &Description{}
+	d.setSpan(pos(0), pos(len(s)))
+
+	text := 0 // start of the pending text
+	for i := 0; i < len(s); {
+		j := strings.Index(s[i:], "{@")
+		if j < 0 {
+			break
+		}
+		i += j
+		tag, n := parseInlineTag(s[i:])
+		if tag == nil {
+			i += len("{@")
+			continue
+		}
+		if text < i {
+			t := &DescText{Text: s[text:i]}
+			t.setSpan(pos(text), pos(i))
+			d.Parts = append(d.Parts, t)
+		}
+		tag.setSpan(pos(i), pos(i+n))
+		d.Parts = append(d.Parts, tag)
+		i += n
+		text = i
+	}
+	if text < len(s) {
+		t := &DescText{Text: s[text:]}
+		t.setSpan(pos(text), d.EndPos)
+		d.Parts = append(d.Parts, t)
+	}
+	return d
+}
+
+// parseInlineTag parses the inline tag at the beginning of s and
+// returns its length in bytes. It returns nil if there isn't one.
+func parseInlineTag(s string) (tag *InlineTag, n int) {
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return nil, 0
+	}
+	body := s[len("{@"):end]
+	name, rest := cutWord(body)
+	if name == "" || strings.ContainsAny(name, "{\n") {
+		return nil, 0
+	}
+	tag = &InlineTag{Name: name}
+	switch name {
+	case "link", "see":
+		tag.Ref, tag.Desc = cutWord(strings.TrimSpace(rest))
+		tag.Desc = strings.TrimSpace(tag.Desc)
+	default:
+		tag.Desc = strings.TrimSpace(rest)
+	}
+	return tag, end + 1
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"mibk.dev/phpdoc"
 	"mibk.dev/phpdoc/phptype"
 )
@@ -43,6 +44,134 @@ func TestParsingDoc(t *testing.T) {
 				PreferOneline: true,
 			},
 		},
+		{
+			doc: `/** @phpstan-param int $id The ID */`,
+			want: &phpdoc.Block{
+				Lines: lines(&phpdoc.ParamTag{
+					Vendor: "phpstan",
+					Param:  &phptype.Param{Type: typ("int"), Name: "id"},
+					Desc:   "The ID",
+				}),
+				PreferOneline: true,
+			},
+		},
+		{
+			doc: `/** @psalm-import-type Row from Foo\Repo as RepoRow */`,
+			want: &phpdoc.Block{
+				Lines: lines(&phpdoc.ImportTypeTag{
+					Vendor: "psalm",
+					Name:   "Row",
+					From:   &phptype.Named{Parts: []string{"Foo", "Repo"}},
+					Alias:  "RepoRow",
+				}),
+				PreferOneline: true,
+			},
+		},
+		{
+			doc: `/**
+				@mixin Foo
+				@param-out int $x
+				@psalm-assert !null $x
+				@phpstan-assert-if-false =Foo $y
+				@phpstan-self-out Foo
+			*/`,
+			want: &phpdoc.Block{
+				Lines: lines(
+					&phpdoc.MixinTag{Class: typ("Foo")},
+					&phpdoc.ParamOutTag{Type: typ("int"), Var: "x"},
+					&phpdoc.AssertTag{Vendor: "psalm", Negated: true, Type: typ("null"), Var: "x"},
+					&phpdoc.AssertTag{Vendor: "phpstan", IfFalse: true, Equal: true, Type: typ("Foo"), Var: "y"},
+					&phpdoc.SelfOutTag{Vendor: "phpstan", Type: typ("Foo")},
+				),
+			},
+		},
+		{
+			doc: `/**
+ * @param int $x The x
+ *     - foo
+ *       bar
+ *    - baz
+ * @see Foo
+ *   Bar
+ * Text.
+ * @param string $y
+ *   The y
+ *     continued.
+ */`,
+			want: &phpdoc.Block{
+				Lines: lines(
+					&phpdoc.ParamTag{
+						Param: &phptype.Param{Type: typ("int"), Name: "x"},
+						Desc:  "The x\n- foo\n  bar\n- baz",
+					},
+					&phpdoc.SeeTag{Ref: "Foo", Desc: "\nBar"},
+					&phpdoc.TextLine{Value: "* Text."},
+					&phpdoc.ParamTag{
+						Param: &phptype.Param{Type: typ("string"), Name: "y"},
+						Desc:  "\nThe y\n  continued.",
+					},
+				),
+			},
+		},
+		{
+			doc: `/**
+				@inheritDoc
+				@psalm-internal Foo\Bar
+				@phpstan-impure
+			*/`,
+			want: &phpdoc.Block{
+				Lines: lines(
+					&phpdoc.FlagTag{Name: "inheritDoc"},
+					&phpdoc.OtherTag{Name: "psalm-internal", Desc: `Foo\Bar`},
+					&phpdoc.FlagTag{Vendor: "phpstan", Name: "impure"},
+				),
+			},
+		},
+		{
+			doc: `/**
+				@deprecated 3.0 Use bar() instead.
+				@since v1.2.0-beta
+				@version GIT: $Id$
+				@see Foo::bar() For details.
+				@link https://example.com/docs
+			*/`,
+			want: &phpdoc.Block{
+				Lines: lines(
+					&phpdoc.DeprecatedTag{Version: "3.0", Desc: "Use bar() instead."},
+					&phpdoc.SinceTag{Version: "v1.2.0-beta"},
+					&phpdoc.VersionTag{Desc: "GIT: $Id$"},
+					&phpdoc.SeeTag{Ref: "Foo::bar()", Desc: "For details."},
+					&phpdoc.LinkTag{URI: "https://example.com/docs"},
+				),
+			},
+		},
+		{
+			doc: `/**
+				@deprecated 2 reasons below
+				@since v2
+				@version 2.4.1+build.5 Stable.
+			*/`,
+			want: &phpdoc.Block{
+				Lines: lines(
+					&phpdoc.DeprecatedTag{Desc: "2 reasons below"},
+					&phpdoc.SinceTag{Version: "v2"},
+					&phpdoc.VersionTag{Version: "2.4.1+build.5", Desc: "Stable."},
+				),
+			},
+		},
+		{
+			doc: `/** @psalm-template-covariant T as object = Foo */`,
+			want: &phpdoc.Block{
+				Lines: lines(&phpdoc.TemplateTag{
+					Vendor:   "psalm",
+					Variance: phptype.Covariant,
+					Param:    "T",
+					Bound:    new(phptype.ObjectShape),
+					Default:  typ("Foo"),
+				}),
+				PreferOneline: true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -52,7 +181,8 @@ func TestParsingDoc(t *testing.T) {
 		}
 
 		allowUnexportedFields := cmp.Exporter(func(reflect.Type) bool { return true })
-		if diff := cmp.Diff(got, tt.want, allowUnexportedFields); diff != "" {
+		ignorePos := cmpopts.IgnoreTypes(phptype.Pos{}, []phptype.Pos{})
+		if diff := cmp.Diff(got, tt.want, allowUnexportedFields, ignorePos); diff != "" {
 			t.Errorf("%q: docs don't match (-got +want)\n%s", tt.doc, diff)
 		}
 	}
@@ -73,6 +203,13 @@ func TestParsingTypes(t *testing.T) {
 
 	types := func(types ...phptype.Type) []phptype.Type { return types }
 	parts := func(parts ...string) []string { return parts }
+	typeParams := func(types ...phptype.Type) []*phptype.TypeParam {
+		var params []*phptype.TypeParam
+		for _, typ := range types {
+			params = append(params, &phptype.TypeParam{Type: typ})
+		}
+		return params
+	}
 
 	tests := []struct {
 		typ  string
@@ -88,11 +225,11 @@ func TestParsingTypes(t *testing.T) {
 		},
 		{
 			typ: `array < string, ?array<string, int > []>`,
-			want: &generic{Base: new(arrayShape), TypeParams: types(
+			want: &generic{Base: new(arrayShape), TypeParams: typeParams(
 				&named{Parts: parts("string")},
 				&array{Elem: &nullable{Type: &generic{
 					Base: new(arrayShape),
-					TypeParams: types(
+					TypeParams: typeParams(
 						&named{Parts: parts("string")},
 						&named{Parts: parts("int")},
 					),
@@ -138,13 +275,188 @@ func TestParsingTypes(t *testing.T) {
 		{
 			typ: `class-string<T>`,
 			want: &generic{Base: &named{Parts: parts("class-string")},
-				TypeParams: types(&named{Parts: parts("T")}),
+				TypeParams: typeParams(&named{Parts: parts("T")}),
 			},
 		},
 		{
 			typ:  `static`,
 			want: &phptype.Named{Parts: []string{"static"}},
 		},
+		{
+			typ:  `int<1, 100>`,
+			want: &phptype.IntRange{Min: int64p(1), Max: int64p(100)},
+		},
+		{
+			typ: `int<min,-1>|-5`,
+			want: &union{Types: types(
+				&phptype.IntRange{Max: int64p(-1)},
+				&phptype.Literal{Kind: phptype.IntLit, Value: "-5"},
+			)},
+		},
+		{
+			typ: `int<0, 1_000>|int<0x10, max>|int<-0x10, 0>|int<017, 0b11111>`,
+			want: &union{Types: types(
+				&phptype.IntRange{Min: int64p(0), Max: int64p(1000)},
+				&phptype.IntRange{Min: int64p(16)},
+				&phptype.IntRange{Min: int64p(-16), Max: int64p(0)},
+				&phptype.IntRange{Min: int64p(15), Max: int64p(31)},
+			)},
+		},
+		{
+			typ: `'a'|"b"|-1|1.5`,
+			want: &union{Types: types(
+				&phptype.Literal{Kind: phptype.StringLit, Value: `'a'`},
+				&phptype.Literal{Kind: phptype.StringLit, Value: `"b"`},
+				&phptype.Literal{Kind: phptype.IntLit, Value: "-1"},
+				&phptype.Literal{Kind: phptype.FloatLit, Value: "1.5"},
+			)},
+		},
+		{
+			typ: `callable(bool $a = FALSE, ?int $b = null, string $c = PHP_EOL)`,
+			want: &phptype.Callable{Name: "callable", Params: []*phptype.Param{
+				{Type: &named{Parts: parts("bool")}, Name: "a", Default: &phptype.Literal{Kind: phptype.BoolLit, Value: "FALSE"}},
+				{Type: &nullable{Type: &named{Parts: parts("int")}}, Name: "b", Default: &phptype.Literal{Kind: phptype.NullLit, Value: "null"}},
+				{Type: &named{Parts: parts("string")}, Name: "c", Default: &phptype.Literal{Kind: phptype.ConstLit, Value: "PHP_EOL"}},
+			}},
+		},
+		{
+			typ: `Collection<covariant Foo, contravariant, *>`,
+			want: &generic{Base: &named{Parts: parts("Collection")},
+				TypeParams: []*phptype.TypeParam{
+					{Variance: phptype.Covariant, Type: &named{Parts: parts("Foo")}},
+					{Type: &named{Parts: parts("contravariant")}},
+					{Wildcard: true},
+				},
+			},
+		},
+		{
+			typ:  `INT < 0 , max , >`,
+			want: &phptype.IntRange{Min: int64p(0)},
+		},
+		{
+			typ: `list{int, string}`,
+			want: &arrayShape{Kind: phptype.List, Elems: []*arrayElem{
+				{Type: &named{Parts: parts("int")}},
+				{Type: &named{Parts: parts("string")}},
+			}},
+		},
+		{
+			typ: `array{a: int, ...<string, mixed>}`,
+			want: &arrayShape{
+				Elems:      []*arrayElem{{Key: "a", Type: &named{Parts: parts("int")}}},
+				Unsealed:   true,
+				ExtraKey:   &named{Parts: parts("string")},
+				ExtraValue: &named{Parts: parts("mixed")},
+			},
+		},
+		{
+			typ:  `non-empty-array{...,}`,
+			want: &arrayShape{Kind: phptype.NonEmptyArray, Unsealed: true},
+		},
+		{
+			typ: `object{'foo-bar': int}`,
+			want: &phptype.ObjectShape{Elems: []*phptype.ObjectElem{
+				{Key: `'foo-bar'`, Type: &named{Parts: parts("int")}},
+			}},
+		},
+		{
+			typ: `\Closure<T of Foo>(T $x=, int=): T`,
+			want: &phptype.Callable{
+				Name: `\Closure`,
+				TemplateParams: []*phptype.TemplateParam{
+					{Name: "T", Bound: &named{Parts: parts("Foo")}},
+				},
+				Params: []*phptype.Param{
+					{Type: &named{Parts: parts("T")}, Name: "x", Optional: true},
+					{Type: &named{Parts: parts("int")}, Optional: true},
+				},
+				Result: &named{Parts: parts("T")},
+			},
+		},
+		{
+			typ: `\Foo\Closure(int)`,
+			want: &phptype.Callable{
+				Name:   `\Foo\Closure`,
+				Params: []*phptype.Param{{Type: &named{Parts: parts("int")}}},
+			},
+		},
+		{
+			typ: `Closure (int)|callable (int)`,
+			want: &union{Types: types(
+				&phptype.Callable{
+					Name:   "Closure",
+					Params: []*phptype.Param{{Type: &named{Parts: parts("int")}}},
+				},
+				&phptype.Callable{
+					Name:   "callable",
+					Params: []*phptype.Param{{Type: &named{Parts: parts("int")}}},
+				},
+			)},
+		},
+		{
+			typ: `Closure<T>|callable<T>`,
+			want: &union{Types: types(
+				&generic{
+					Base:       &named{Parts: parts("Closure")},
+					TypeParams: typeParams(&named{Parts: parts("T")}),
+				},
+				&generic{
+					Base:       &phptype.Callable{Name: "callable"},
+					TypeParams: typeParams(&named{Parts: parts("T")}),
+				},
+			)},
+		},
+		{
+			typ: `pure-callable(string): bool`,
+			want: &phptype.Callable{
+				Name:   "pure-callable",
+				Params: []*phptype.Param{{Type: &named{Parts: parts("string")}}},
+				Result: &named{Parts: parts("bool")},
+			},
+		},
+		{
+			typ: `T['key'][]`,
+			want: &array{Elem: &phptype.OffsetAccess{
+				Type:   &named{Parts: parts("T")},
+				Offset: &phptype.Literal{Value: `'key'`},
+			}},
+		},
+		{
+			typ: `key-of<T>|int-mask<1, 2, 4>`,
+			want: &union{Types: types(
+				&phptype.Operator{Op: phptype.KeyOf, Args: types(&named{Parts: parts("T")})},
+				&phptype.Operator{Op: phptype.IntMask, Args: types(
+					&phptype.Literal{Kind: phptype.IntLit, Value: "1"},
+					&phptype.Literal{Kind: phptype.IntLit, Value: "2"},
+					&phptype.Literal{Kind: phptype.IntLit, Value: "4"},
+				)},
+			)},
+		},
+		{
+			typ: `\key-of<T>`,
+			want: &generic{Base: &named{Parts: parts("key-of"), Global: true},
+				TypeParams: typeParams(&named{Parts: parts("T")}),
+			},
+		},
+		{
+			typ: `($size is positive-int ? non-empty-array : array)`,
+			want: &phptype.Conditional{
+				Param:  "size",
+				Target: &named{Parts: parts("positive-int")},
+				If:     &named{Parts: parts("non-empty-array")},
+				Else:   new(arrayShape),
+			},
+		},
+		{
+			typ: `(T is not int ? (string) : bool)[]`,
+			want: &array{Elem: &phptype.Conditional{
+				Subject: &named{Parts: parts("T")},
+				Negated: true,
+				Target:  &named{Parts: parts("int")},
+				If:      &parens{Type: &named{Parts: parts("string")}},
+				Else:    &named{Parts: parts("bool")},
+			}},
+		},
 		{
 			typ:  `self`,
 			want: &phptype.Named{Parts: []string{"self"}},
@@ -158,12 +470,202 @@ func TestParsingTypes(t *testing.T) {
 		}
 
 		allowUnexportedFields := cmp.Exporter(func(reflect.Type) bool { return true })
-		if diff := cmp.Diff(got, tt.want, allowUnexportedFields); diff != "" {
+		ignorePos := cmpopts.IgnoreTypes(phptype.Pos{})
+		if diff := cmp.Diff(got, tt.want, allowUnexportedFields, ignorePos); diff != "" {
 			t.Errorf("%q: types don't match (-got +want)\n%s", tt.typ, diff)
 		}
 	}
 }
 
+func int64p(v int64) *int64 { return &v }
+
+func TestTypeSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		typ     string
+		wantErr string
+	}{
+		{" int\n", `<nil>`},
+		{"", `line:1:1: expecting ( or basic type, found EOF`},
+		{"int $x", `line:1:5: unexpected Var("$x") after type`},
+		{"Foo&", `line:1:4: unexpected & after type`},
+		{"array<int", `line:1:10: expecting >, found EOF`},
+		{"int\n|string", `line:2:1: unexpected | after type`},
+		{"?int */", `line:1:6: unexpected */ after type`},
+		{"($x)", `line:1:4: unexpected ), expecting is`},
+		{"(T is int ? string)", `line:1:19: expecting :, found )`},
+		{"int<5, 1>", `line:1:1: invalid int range: min 5 is greater than max 1`},
+		{"int<max, 1>", `line:1:5: unexpected Ident("max"), expecting integer or min`},
+		{"int<0>", `line:1:6: expecting ,, found >`},
+		{"value-of<A, B>", `line:1:14: value-of expects exactly one type argument`},
+		{"T['a'", `line:1:6: expecting ], found EOF`},
+		{"Closure<T of int>", `line:1:11: expecting >, found Ident("of")`},
+		{`\Foo\pure-Closure(int)`, `line:1:18: unexpected ( after type`},
+		{`"\u{110000}"`, `line:1:1: invalid string literal "\u{110000}"`},
+		{"array{..., int}", `line:1:12: expecting }, found Ident("int")`},
+		{"list{...<int, string, bool>}", `line:1:21: expecting >, found ,`},
+		{"int<99999999999999999999, max>", `line:1:5: invalid int range bound 99999999999999999999`},
+	}
+
+	for _, tt := range tests {
+		typ, err := phpdoc.ParseTypeString(tt.typ)
+		errStr := "<nil>"
+		if err != nil {
+			if typ != nil {
+				t.Fatalf("%q: got %+v on err", tt.typ, typ)
+			}
+			if _, ok := err.(*phpdoc.SyntaxError); !ok {
+				t.Errorf("%q: got %T, want *phpdoc.SyntaxError", tt.typ, err)
+			}
+			errStr = err.Error()
+		}
+		if errStr != tt.wantErr {
+			t.Errorf("%q:\n got %s\nwant %s", tt.typ, errStr, tt.wantErr)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	const doc = `/**
+ * Does  things.
+ * @param  array<int, ?\Foo> $x The x
+ * @return callable(int $a = 3): void
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := block.Lines[1].(*phpdoc.ParamTag)
+	generic := param.Param.Type.(*phptype.Generic)
+	ret := block.Lines[2].(*phpdoc.ReturnTag)
+	callable := ret.Type.(*phptype.Callable)
+
+	tests := []struct {
+		node phptype.Node
+		want string
+	}{
+		{block, doc},
+		{block.Lines[0], "* Does  things."},
+		{param, "@param  array<int, ?\\Foo> $x The x"},
+		{param.Param, "array<int, ?\\Foo> $x"},
+		{generic, "array<int, ?\\Foo>"},
+		{generic.Base, "array"},
+		{generic.TypeParams[1], "?\\Foo"},
+		{generic.TypeParams[1].Type.(*phptype.Nullable).Type, "\\Foo"},
+		{callable, "callable(int $a = 3): void"},
+		{callable.Params[0], "int $a = 3"},
+		{callable.Params[0].Default, "3"},
+		{callable.Result, "void"},
+	}
+	for _, tt := range tests {
+		pos, end := tt.node.Pos(), tt.node.End()
+		if got := doc[pos.Offset:end.Offset]; got != tt.want {
+			t.Errorf("%T: got %q, want %q", tt.node, got, tt.want)
+		}
+	}
+
+	if pos := generic.TypeParams[1].Pos(); pos.Line != 3 || pos.Column != 23 {
+		t.Errorf("got pos %v, want 3:23", pos)
+	}
+	if end := ret.End(); end.Line != 4 || end.Column != 38 {
+		t.Errorf("got end %v, want 4:38", end)
+	}
+}
+
+func TestPositionsInvalidUTF8(t *testing.T) {
+	const doc = "/** @var int \x8b\x8b */"
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := block.End().Offset; end != len(doc) {
+		t.Errorf("block ends at offset %d, want %d", end, len(doc))
+	}
+	if end := block.Lines[0].End().Offset; end != len(doc)-len(" */") {
+		t.Errorf("tag ends at offset %d, want %d", end, len(doc)-len(" */"))
+	}
+}
+
+func TestParseDescription(t *testing.T) {
+	const doc = `/**
+ * See {@link https://example.com The docs}, {@ bad} {@unterminated
+ * @param int $x Like {@see Foo::bar()}.
+ * @see Foo Foo {@inheritDoc}
+ * @return int One,
+ *   {@link https://example.com
+ *     two}
+ * @throws \Exception
+ *   If {@see Foo} fails.
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line  phpdoc.Line
+		parts []phpdoc.DescPart
+		src   []string
+	}{
+		{
+			block.Lines[0],
+			[]phpdoc.DescPart{
+				&phpdoc.DescText{Text: "See "},
+				&phpdoc.InlineTag{Name: "link", Ref: "https://example.com", Desc: "The docs"},
+				&phpdoc.DescText{Text: ", {@ bad} {@unterminated"},
+			},
+			[]string{"See ", "{@link https://example.com The docs}", ", {@ bad} {@unterminated"},
+		},
+		{
+			block.Lines[1],
+			[]phpdoc.DescPart{
+				&phpdoc.DescText{Text: "Like "},
+				&phpdoc.InlineTag{Name: "see", Ref: "Foo::bar()"},
+				&phpdoc.DescText{Text: "."},
+			},
+			[]string{"Like ", "{@see Foo::bar()}", "."},
+		},
+		{
+			block.Lines[2],
+			[]phpdoc.DescPart{
+				&phpdoc.DescText{Text: "Foo "},
+				&phpdoc.InlineTag{Name: "inheritDoc"},
+			},
+			[]string{"Foo ", "{@inheritDoc}"},
+		},
+		{
+			block.Lines[3],
+			[]phpdoc.DescPart{
+				&phpdoc.DescText{Text: "One,\n"},
+				&phpdoc.InlineTag{Name: "link", Ref: "https://example.com", Desc: "two"},
+			},
+			[]string{"One,\n *   ", "{@link https://example.com\n *     two}"},
+		},
+		{
+			block.Lines[4],
+			[]phpdoc.DescPart{
+				&phpdoc.DescText{Text: "\nIf "},
+				&phpdoc.InlineTag{Name: "see", Ref: "Foo"},
+				&phpdoc.DescText{Text: " fails."},
+			},
+			[]string{"\n *   If ", "{@see Foo}", " fails."},
+		},
+	}
+	for _, tt := range tests {
+		desc := phpdoc.ParseDescription(tt.line)
+		allowUnexportedFields := cmp.Exporter(func(reflect.Type) bool { return true })
+		ignorePos := cmpopts.IgnoreTypes(phptype.Pos{})
+		if diff := cmp.Diff(desc.Parts, tt.parts, allowUnexportedFields, ignorePos); diff != "" {
+			t.Errorf("%T: parts differ: (-got +want)\n%s", tt.line, diff)
+			continue
+		}
+		for i, part := range desc.Parts {
+			if got := doc[part.Pos().Offset:part.End().Offset]; got != tt.src[i] {
+				t.Errorf("%T: got %q, want %q", part, got, tt.src[i])
+			}
+		}
+	}
+}
+
 func TestSyntaxErrors(t *testing.T) {
 	tests := []struct {
 		doc     string
@@ -225,6 +727,14 @@ func TestSyntaxErrors(t *testing.T) {
 			`/**@var ? DateTime::FORMAT */`,
 			`line:1:21: constant fetch cannot be nullable`,
 		},
+		{
+			`/**@phpstan-assert ! int*/`,
+			`line:1:25: expecting Var, found */`,
+		},
+		{
+			`/**@phpstan-import-type Row as Foo*/`,
+			`line:1:29: unexpected Ident("as"), expecting from`,
+		},
 		{
 			`/**@var DateTime::ANY_ * */`,
 			`line:1:26: invalid position of *, did you mean to write ANY_*?`,
@@ -245,3 +755,53 @@ func TestSyntaxErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestAllErrors(t *testing.T) {
+	const doc = `/**
+ * Summary.
+ * @param array<int  string> $a Broken
+ * @param int $b Fine
+ * @param int<5, 1> $c Empty
+ *     range.
+ * @method x():
+ * @return ?bool
+ */`
+	block, err := phpdoc.ParseWithMode(strings.NewReader(doc), phpdoc.AllErrors)
+	list, ok := err.(phpdoc.ErrorList)
+	if !ok {
+		t.Fatalf("got err %v, want ErrorList", err)
+	}
+	wantErrs := []string{
+		`line:3:22: expecting >, found Ident("string")`,
+		`line:5:11: invalid int range: min 5 is greater than max 1`,
+		`line:7:16: unexpected :, expecting description`,
+	}
+	if len(list) != len(wantErrs) {
+		t.Fatalf("got %d errors, want %d: %v", len(list), len(wantErrs), list)
+	}
+	for i, err := range list {
+		if got := err.Error(); got != wantErrs[i] {
+			t.Errorf("error %d:\n got %s\nwant %s", i, got, wantErrs[i])
+		}
+	}
+
+	want := &phpdoc.Block{Lines: []phpdoc.Line{
+		&phpdoc.TextLine{Value: "* Summary."},
+		&phpdoc.BadTag{Text: "@param array<int  string> $a Broken"},
+		&phpdoc.ParamTag{
+			Param: &phptype.Param{Type: &phptype.Named{Parts: []string{"int"}}, Name: "b"},
+			Desc:  "Fine",
+		},
+		&phpdoc.BadTag{Text: "@param int<5, 1> $c Empty\n *     range."},
+		&phpdoc.BadTag{Text: "@method x():"},
+		&phpdoc.ReturnTag{Type: &phptype.Nullable{Type: &phptype.Named{Parts: []string{"bool"}}}},
+	}}
+	allowUnexportedFields := cmp.Exporter(func(reflect.Type) bool { return true })
+	ignorePos := cmpopts.IgnoreTypes(phptype.Pos{}, []phptype.Pos{})
+	if diff := cmp.Diff(block, want, allowUnexportedFields, ignorePos); diff != "" {
+		t.Errorf("docs don't match (-got +want)\n%s", diff)
+	}
+	if got, want := err.Error(), wantErrs[0]+" (and 2 more errors)"; got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+package phptype
+
+import "fmt"
+
+// Clone returns a deep copy of the node n. The result has the same
+// dynamic type as n.
+func Clone(n Node) Node {
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case *Union:
+		c := *n
+		c.Types = cloneList(n.Types)
+		return &c
+	case *Intersect:
+		c := *n
+		c.Types = cloneList(n.Types)
+		return &c
+	case *Paren:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *Array:
+		c := *n
+		c.Elem = cloneType(n.Elem)
+		return &c
+	case *Nullable:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *OffsetAccess:
+		c := *n
+		c.Type = cloneType(n.Type)
+		c.Offset = cloneType(n.Offset)
+		return &c
+	case *Operator:
+		c := *n
+		c.Args = cloneList(n.Args)
+		return &c
+	case *ArrayShape:
+		c := *n
+		if n.Elems != nil {
+			c.Elems = make([]*ArrayElem, len(n.Elems))
+			for i, elem := range n.Elems {
+				c.Elems[i] = Clone(elem).(*ArrayElem)
+			}
+		}
+		c.ExtraKey = cloneType(n.ExtraKey)
+		c.ExtraValue = cloneType(n.ExtraValue)
+		return &c
+	case *ArrayElem:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *ObjectShape:
+		c := *n
+		c.Elems = nil
+		for _, elem := range n.Elems {
+			c.Elems = append(c.Elems, Clone(elem).(*ObjectElem))
+		}
+		return &c
+	case *ObjectElem:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *Generic:
+		c := *n
+		c.Base = cloneType(n.Base)
+		if n.TypeParams != nil {
+			c.TypeParams = make([]*TypeParam, len(n.TypeParams))
+			for i, tp := range n.TypeParams {
+				c.TypeParams[i] = Clone(tp).(*TypeParam)
+			}
+		}
+		return &c
+	case *TypeParam:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *ConstFetch:
+		c := *n
+		c.Class = cloneType(n.Class)
+		return &c
+	case *Literal:
+		c := *n
+		return &c
+	case *IntRange:
+		c := *n
+		c.Min = cloneBound(n.Min)
+		c.Max = cloneBound(n.Max)
+		return &c
+	case *Named:
+		c := *n
+		c.Parts = append([]string(nil), n.Parts...)
+		return &c
+	case *This:
+		c := *n
+		return &c
+	case *Param:
+		c := *n
+		c.Type = cloneType(n.Type)
+		if n.Default != nil {
+			c.Default = Clone(n.Default).(*Literal)
+		}
+		return &c
+	case *Callable:
+		c := *n
+		if n.TemplateParams != nil {
+			c.TemplateParams = make([]*TemplateParam, len(n.TemplateParams))
+			for i, tp := range n.TemplateParams {
+				c.TemplateParams[i] = Clone(tp).(*TemplateParam)
+			}
+		}
+		c.Params = CloneParams(n.Params)
+		c.Result = cloneType(n.Result)
+		return &c
+	case *TemplateParam:
+		c := *n
+		c.Bound = cloneType(n.Bound)
+		return &c
+	case *Conditional:
+		c := *n
+		c.Subject = cloneType(n.Subject)
+		c.Target = cloneType(n.Target)
+		c.If = cloneType(n.If)
+		c.Else = cloneType(n.Else)
+		return &c
+	default:
+		panic(fmt.Sprintf("phptype.Clone: unexpected node type %T", n))
+	}
+}
+
+// CloneParams returns a deep copy of the param list params.
+func CloneParams(params []*Param) []*Param {
+	if params == nil {
+		return nil
+	}
+	c := make([]*Param, len(params))
+	for i, par := range params {
+		c[i] = Clone(par).(*Param)
+	}
+	return c
+}
+
+func cloneBound(b *int64) *int64 {
+	if b == nil {
+		return nil
+	}
+	v := *b
+	return &v
+}
+
+func cloneType(t Type) Type {
+	if t == nil {
+		return nil
+	}
+	return Clone(t).(Type)
+}
+
+func cloneList(list []Type) []Type {
+	if list == nil {
+		return nil
+	}
+	c := make([]Type, len(list))
+	for i, t := range list {
+		c[i] = cloneType(t)
+	}
+	return c
+}
@@ -0,0 +1,40 @@
+package phptype_test
+
+import (
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestClone(t *testing.T) {
+	const input = `array{a: Foo\Bar, b?: callable(int $x = 3): ?C}|array{}|D::E`
+	typ, err := phpdoc.ParseTypeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := phptype.Clone(typ).(phptype.Type)
+	if !phptype.Equal(typ, clone) {
+		t.Fatalf("clone %s differs from the original", clone)
+	}
+
+	// Modify the clone to make sure nothing is shared.
+	phptype.Inspect(clone, func(n phptype.Node) bool {
+		switch n := n.(type) {
+		case *phptype.Named:
+			n.Parts[0] = "X"
+		case *phptype.ArrayElem:
+			n.Key = "k"
+		case *phptype.Literal:
+			n.Value = "0"
+		}
+		return true
+	})
+	if got := typ.String(); got != input {
+		t.Errorf("original modified:\n got %s\nwant %s", got, input)
+	}
+	const want = `array{k: X\Bar, k?: callable(X $x = 0): ?X}|array{}|X::E`
+	if got := clone.String(); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package phptype
+
+import "fmt"
+
+// An EqualOption modifies the comparison performed by Equal.
+type EqualOption int
+
+const (
+	// IgnoreParens makes Equal ignore Paren wrappers.
+	IgnoreParens EqualOption = iota + 1
+
+	// IgnoreOrder makes Equal ignore the order of types in unions
+	// and intersections.
+	IgnoreOrder
+)
+
+// Equal reports whether the nodes x and y are structurally equal.
+// Source positions are not compared.
+func Equal(x, y Node, opts ...EqualOption) bool {
+	var e equaler
+	for _, opt := range opts {
+		switch opt {
+		case IgnoreParens:
+			e.ignoreParens = true
+		case IgnoreOrder:
+			e.ignoreOrder = true
+		}
+	}
+	return e.equal(x, y)
+}
+
+type equaler struct {
+	ignoreParens bool
+	ignoreOrder  bool
+}
+
+func (e *equaler) equal(x, y Node) bool {
+	if e.ignoreParens {
+		x, y = unparen(x), unparen(y)
+	}
+	if x == nil || y == nil {
+		return x == y
+	}
+
+	switch x := x.(type) {
+	case *Union:
+		y, ok := y.(*Union)
+		return ok && e.equalSet(x.Types, y.Types)
+	case *Intersect:
+		y, ok := y.(*Intersect)
+		return ok && e.equalSet(x.Types, y.Types)
+	case *Paren:
+		y, ok := y.(*Paren)
+		return ok && e.equal(x.Type, y.Type)
+	case *Array:
+		y, ok := y.(*Array)
+		return ok && e.equal(x.Elem, y.Elem)
+	case *Nullable:
+		y, ok := y.(*Nullable)
+		return ok && e.equal(x.Type, y.Type)
+	case *OffsetAccess:
+		y, ok := y.(*OffsetAccess)
+		return ok && e.equal(x.Type, y.Type) && e.equal(x.Offset, y.Offset)
+	case *Operator:
+		y, ok := y.(*Operator)
+		return ok && x.Op == y.Op && e.equalList(x.Args, y.Args)
+	case *ArrayShape:
+		y, ok := y.(*ArrayShape)
+		if !ok || x.Kind != y.Kind || x.Unsealed != y.Unsealed || len(x.Elems) != len(y.Elems) ||
+			(x.Elems == nil) != (y.Elems == nil) {
+			return false
+		}
+		if !e.equal(x.ExtraKey, y.ExtraKey) || !e.equal(x.ExtraValue, y.ExtraValue) {
+			return false
+		}
+		for i := range x.Elems {
+			if !e.equal(x.Elems[i], y.Elems[i]) {
+				return false
+			}
+		}
+		return true
+	case *ArrayElem:
+		y, ok := y.(*ArrayElem)
+		return ok && x.Key == y.Key && x.Optional == y.Optional && e.equal(x.Type, y.Type)
+	case *ObjectShape:
+		y, ok := y.(*ObjectShape)
+		if !ok || len(x.Elems) != len(y.Elems) {
+			return false
+		}
+		for i := range x.Elems {
+			if !e.equal(x.Elems[i], y.Elems[i]) {
+				return false
+			}
+		}
+		return true
+	case *ObjectElem:
+		y, ok := y.(*ObjectElem)
+		return ok && x.Key == y.Key && x.Optional == y.Optional && e.equal(x.Type, y.Type)
+	case *Generic:
+		y, ok := y.(*Generic)
+		if !ok || len(x.TypeParams) != len(y.TypeParams) {
+			return false
+		}
+		for i := range x.TypeParams {
+			if !e.equal(x.TypeParams[i], y.TypeParams[i]) {
+				return false
+			}
+		}
+		return e.equal(x.Base, y.Base)
+	case *TypeParam:
+		y, ok := y.(*TypeParam)
+		return ok && x.Variance == y.Variance && x.Wildcard == y.Wildcard && e.equal(x.Type, y.Type)
+	case *ConstFetch:
+		y, ok := y.(*ConstFetch)
+		return ok && x.Name == y.Name && e.equal(x.Class, y.Class)
+	case *Literal:
+		y, ok := y.(*Literal)
+		return ok && x.Kind == y.Kind && x.Value == y.Value
+	case *IntRange:
+		y, ok := y.(*IntRange)
+		return ok && equalBound(x.Min, y.Min) && equalBound(x.Max, y.Max)
+	case *Named:
+		y, ok := y.(*Named)
+		if !ok || x.Global != y.Global || len(x.Parts) != len(y.Parts) {
+			return false
+		}
+		for i := range x.Parts {
+			if x.Parts[i] != y.Parts[i] {
+				return false
+			}
+		}
+		return true
+	case *This:
+		_, ok := y.(*This)
+		return ok
+	case *Param:
+		y, ok := y.(*Param)
+		if !ok || x.ByRef != y.ByRef || x.Variadic != y.Variadic || x.Name != y.Name || x.Optional != y.Optional {
+			return false
+		}
+		if (x.Default == nil) != (y.Default == nil) ||
+			x.Default != nil && !e.equal(x.Default, y.Default) {
+			return false
+		}
+		return e.equal(x.Type, y.Type)
+	case *Callable:
+		y, ok := y.(*Callable)
+		if !ok || x.Name != y.Name || len(x.TemplateParams) != len(y.TemplateParams) || len(x.Params) != len(y.Params) {
+			return false
+		}
+		for i := range x.TemplateParams {
+			if !e.equal(x.TemplateParams[i], y.TemplateParams[i]) {
+				return false
+			}
+		}
+		for i := range x.Params {
+			if !e.equal(x.Params[i], y.Params[i]) {
+				return false
+			}
+		}
+		return e.equal(x.Result, y.Result)
+	case *TemplateParam:
+		y, ok := y.(*TemplateParam)
+		return ok && x.Name == y.Name && e.equal(x.Bound, y.Bound)
+	case *Conditional:
+		y, ok := y.(*Conditional)
+		return ok && x.Param == y.Param && x.Negated == y.Negated &&
+			e.equal(x.Subject, y.Subject) && e.equal(x.Target, y.Target) &&
+			e.equal(x.If, y.If) && e.equal(x.Else, y.Else)
+	default:
+		panic(fmt.Sprintf("phptype.Equal: unexpected node type %T", x))
+	}
+}
+
+func (e *equaler) equalList(x, y []Type) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !e.equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalSet is like equalList, but it ignores the order of the types
+// in the IgnoreOrder mode.
+func (e *equaler) equalSet(x, y []Type) bool {
+	if !e.ignoreOrder {
+		return e.equalList(x, y)
+	}
+	if len(x) != len(y) {
+		return false
+	}
+	used := make([]bool, len(y))
+X:
+	for _, tx := range x {
+		for i, ty := range y {
+			if !used[i] && e.equal(tx, ty) {
+				used[i] = true
+				continue X
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func equalBound(x, y *int64) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
+
+// unparen returns n with all Paren wrappers stripped.
+func unparen(n Node) Node {
+	for {
+		p, ok := n.(*Paren)
+		if !ok {
+			return n
+		}
+		n = p.Type
+	}
+}
@@ -0,0 +1,51 @@
+package phptype_test
+
+import (
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		x, y string
+		opts []phptype.EqualOption
+		want bool
+	}{
+		{`int`, `int`, nil, true},
+		{`int`, `string`, nil, false},
+		{`\Foo\Bar`, `Foo\Bar`, nil, false},
+		{`array<int, Foo>`, `array<int,Foo>`, nil, true},
+		{`array<int, Foo>`, `array<Foo, int>`, nil, false},
+		{`array{a: int, b?: string}`, `array{a: int, b: string}`, nil, false},
+		{`list{}`, `list{ }`, nil, true},
+		{`array{}`, `array`, nil, false},
+		{`callable(int $x = 3): void`, `callable(int $x = 3): void`, nil, true},
+		{`callable(int $x = 3): void`, `callable(int $x = 4): void`, nil, false},
+		{`callable(int $x = 3): void`, `callable(int $x): void`, nil, false},
+		{`int|string`, `string|int`, nil, false},
+		{`int|string`, `string|int`, []phptype.EqualOption{phptype.IgnoreOrder}, true},
+		{`A&B&A`, `B&A&B`, []phptype.EqualOption{phptype.IgnoreOrder}, false},
+		{`(int)`, `int`, nil, false},
+		{`((int))[]`, `int[]`, []phptype.EqualOption{phptype.IgnoreParens}, true},
+		{`(A&B)|null`, `null|(B&A)`, nil, false},
+		{`(A&B)|null`, `null|((B&A))`, []phptype.EqualOption{phptype.IgnoreParens, phptype.IgnoreOrder}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.x+" "+tt.y, func(t *testing.T) {
+			x, err := phpdoc.ParseTypeString(tt.x)
+			if err != nil {
+				t.Fatal(err)
+			}
+			y, err := phpdoc.ParseTypeString(tt.y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := phptype.Equal(x, y, tt.opts...); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
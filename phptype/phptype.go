@@ -2,10 +2,50 @@
 // syntax trees.
 package phptype
 
-// A Type is the interface that represents all PHP types.
-type Type interface{ aType() }
+import "fmt"
 
-type typ struct{}
+// A Pos represents a position in the source text.
+type Pos struct {
+	Line   int // line number, starting at 1
+	Column int // column number, starting at 1 (character count)
+	Offset int // byte offset, starting at 0
+}
+
+// IsValid reports whether the position is valid.
+func (p Pos) IsValid() bool { return p.Line > 0 }
+
+func (p Pos) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// A Node is the interface implemented by all nodes of a PHP type
+// syntax tree.
+type Node interface {
+	Pos() Pos // position of the first character belonging to the node
+	End() Pos // position of the first character immediately after the node
+}
+
+// node records the source range of a node. Both positions are zero
+// for nodes that weren't created by the parser.
+type node struct {
+	StartPos, EndPos Pos
+}
+
+func (n *node) Pos() Pos { return n.StartPos }
+func (n *node) End() Pos { return n.EndPos }
+
+// A Type is the interface that represents all PHP types. The String
+// method returns the canonical text of the type.
+type Type interface {
+	Node
+	fmt.Stringer
+	aType()
+}
+
+type typ struct{ node }
 
 func (*typ) aType() {}
 
@@ -33,6 +73,13 @@ type Array struct {
 	Elem Type
 }
 
+// An OffsetAccess represents an offset access type, such as T['key'].
+type OffsetAccess struct {
+	typ
+	Type   Type
+	Offset Type
+}
+
 // Nullable represents a nullable type.
 type Nullable struct {
 	typ
@@ -43,11 +90,38 @@ type Nullable struct {
 // in the ordered-map mode.
 type ArrayShape struct {
 	typ
-	Elems []*ArrayElem
+	Kind     ArrayKind
+	Elems    []*ArrayElem // nil for a bare array, e.g. array vs array{}
+	Unsealed bool         // the shape ends with ...
+
+	// ExtraKey and ExtraValue are the types of the extra elements
+	// of an unsealed shape, e.g. array{a: int, ...<string, mixed>}.
+	// Either may be nil.
+	ExtraKey, ExtraValue Type
+}
+
+// An ArrayKind is the kind of an array shape.
+type ArrayKind int
+
+const (
+	PlainArray    ArrayKind = iota // array
+	List                           // list
+	NonEmptyArray                  // non-empty-array
+	NonEmptyList                   // non-empty-list
+)
+
+var arrayKinds = [...]string{
+	PlainArray:    "array",
+	List:          "list",
+	NonEmptyArray: "non-empty-array",
+	NonEmptyList:  "non-empty-list",
 }
 
+func (k ArrayKind) String() string { return arrayKinds[k] }
+
 // An ArrayElem represents a key-value element of ArrayShape.
 type ArrayElem struct {
+	node
 	Key      string // or "" if for implicit keys
 	Type     Type
 	Optional bool
@@ -61,7 +135,8 @@ type ObjectShape struct {
 
 // An ObjectElem represents a key-value element of ObjectShape.
 type ObjectElem struct {
-	Key      string
+	node
+	Key      string // identifier or quoted string
 	Type     Type
 	Optional bool
 }
@@ -70,18 +145,116 @@ type ObjectElem struct {
 type Generic struct {
 	typ
 	Base       Type
-	TypeParams []Type
+	TypeParams []*TypeParam
+}
+
+// A TypeParam represents a type argument of Generic, such as
+// covariant Foo in Collection<covariant Foo>, or the * wildcard.
+type TypeParam struct {
+	node
+	Variance Variance
+	Wildcard bool // *
+	Type     Type // or nil if Wildcard
+}
+
+// A Variance represents the variance of a type argument or template.
+type Variance int
+
+const (
+	Invariant     Variance = iota
+	Covariant              // covariant
+	Contravariant          // contravariant
+)
+
+var variances = [...]string{
+	Invariant:     "invariant",
+	Covariant:     "covariant",
+	Contravariant: "contravariant",
 }
 
+func (v Variance) String() string { return variances[v] }
+
 type ConstFetch struct {
 	typ
 	Class Type
 	Name  string
 }
 
+// A Literal represents a literal value, such as 'foo', -1, or 1.5.
 type Literal struct {
 	typ
-	Value string
+	Kind  LitKind
+	Value string // literal source text, e.g. 'foo' or 0x1F
+}
+
+// A LitKind is the kind of a literal.
+type LitKind int
+
+const (
+	StringLit LitKind = iota // 'foo' or "foo"
+	IntLit                   // 42, -1, 0x1F, 1_000
+	FloatLit                 // 1.5, 1e3
+	BoolLit                  // true or false
+	NullLit                  // null
+	ConstLit                 // other identifier, e.g. PHP_EOL
+)
+
+var litKinds = [...]string{
+	StringLit: "string",
+	IntLit:    "int",
+	FloatLit:  "float",
+	BoolLit:   "bool",
+	NullLit:   "null",
+	ConstLit:  "const",
+}
+
+func (k LitKind) String() string { return litKinds[k] }
+
+// An OpKind is the kind of a type operator.
+type OpKind int
+
+const (
+	KeyOf     OpKind = iota // key-of
+	ValueOf                 // value-of
+	IntMask                 // int-mask
+	IntMaskOf               // int-mask-of
+	New                     // new
+)
+
+var opKinds = [...]string{
+	KeyOf:     "key-of",
+	ValueOf:   "value-of",
+	IntMask:   "int-mask",
+	IntMaskOf: "int-mask-of",
+	New:       "new",
+}
+
+func (k OpKind) String() string { return opKinds[k] }
+
+// LookupOp returns the type operator of the given name.
+func LookupOp(name string) (op OpKind, ok bool) {
+	for k, s := range opKinds {
+		if s == name {
+			return OpKind(k), true
+		}
+	}
+	return 0, false
+}
+
+// An Operator represents a type operator applied to its arguments,
+// such as key-of<T> or int-mask<1, 2, 4>.
+type Operator struct {
+	typ
+	Op   OpKind
+	Args []Type
+}
+
+// An IntRange represents an integer range, such as int<0, max>.
+// Unlike the text of a Literal, the bounds are not kept verbatim;
+// they are printed in decimal, e.g. int<0x10, 1_000> as int<16, 1000>.
+type IntRange struct {
+	typ
+	Min, Max *int64 // or nil if unbounded
 }
 
 // Named represents a (possibly qualified or fully qualified) PHP
@@ -97,15 +270,41 @@ type Named struct {
 type This struct{ typ }
 
 type Param struct {
+	node
 	Type     Type
 	ByRef    bool // pass by reference
 	Variadic bool
 	Name     string
 	Default  *Literal // or nil
+	Optional bool     // marked optional by a trailing = without a value
 }
 
+// A Callable represents a callable type, such as callable(int): void
+// or \Closure<T>(T): T.
 type Callable struct {
 	typ
-	Params []*Param
-	Result Type
+	Name           string // callable, Closure, \Closure, pure-callable, or pure-Closure; "" means callable
+	TemplateParams []*TemplateParam
+	Params         []*Param
+	Result         Type
+}
+
+// A TemplateParam represents a template type parameter of a callable,
+// such as T of Foo in callable<T of Foo>(T): T.
+type TemplateParam struct {
+	node
+	Name  string
+	Bound Type // or nil
+}
+
+// A Conditional represents a conditional type, such as
+// ($size is positive-int ? non-empty-array : array). The parentheses
+// are part of the node.
+type Conditional struct {
+	typ
+	Param    string // subject parameter name without the $, or ""
+	Subject  Type   // subject type (e.g. a template name), or nil if Param != ""
+	Negated  bool   // is not
+	Target   Type
+	If, Else Type
 }
@@ -0,0 +1,272 @@
+package phptype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func (t *Union) String() string         { return sprint(t) }
+func (t *Intersect) String() string     { return sprint(t) }
+func (t *Paren) String() string         { return sprint(t) }
+func (t *Array) String() string         { return sprint(t) }
+func (t *Nullable) String() string      { return sprint(t) }
+func (t *OffsetAccess) String() string  { return sprint(t) }
+func (t *Operator) String() string      { return sprint(t) }
+func (t *ArrayShape) String() string    { return sprint(t) }
+func (e *ArrayElem) String() string     { return sprint(e) }
+func (t *ObjectShape) String() string   { return sprint(t) }
+func (e *ObjectElem) String() string    { return sprint(e) }
+func (t *Generic) String() string       { return sprint(t) }
+func (t *ConstFetch) String() string    { return sprint(t) }
+func (t *Literal) String() string       { return sprint(t) }
+func (t *IntRange) String() string      { return sprint(t) }
+func (t *Named) String() string         { return sprint(t) }
+func (t *This) String() string          { return sprint(t) }
+func (p *Param) String() string         { return sprint(p) }
+func (p *TemplateParam) String() string { return sprint(p) }
+func (p *TypeParam) String() string     { return sprint(p) }
+func (t *Callable) String() string      { return sprint(t) }
+func (t *Conditional) String() string   { return sprint(t) }
+
+// Suffix returns the canonical text of the parameter p following its
+// type, e.g. "&$x = 1", or "=" for an unnamed optional parameter.
+func (p *Param) Suffix() string {
+	pr := new(printer)
+	pr.printParamSuffix(p)
+	return pr.buf.String()
+}
+
+// sprint returns the canonical text of the node n.
+func sprint(n Node) string {
+	p := new(printer)
+	p.print(n)
+	return p.buf.String()
+}
+
+type printer struct {
+	buf strings.Builder
+}
+
+func (p *printer) print(args ...interface{}) {
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case Node:
+			p.printNode(arg)
+		case []*Param:
+			p.print('(')
+			for i, par := range arg {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.print(par)
+			}
+			p.print(')')
+		case string:
+			p.buf.WriteString(arg)
+		case rune:
+			p.buf.WriteRune(arg)
+		default:
+			panic(fmt.Sprintf("unsupported type %T", arg))
+		}
+	}
+}
+
+func (p *printer) printParamSuffix(par *Param) {
+	if par.Name != "" {
+		if par.ByRef {
+			p.print('&')
+		}
+		if par.Variadic {
+			p.print("...")
+		}
+		p.print('$', par.Name)
+	}
+	if par.Default != nil {
+		p.print(" = ", par.Default)
+	} else if par.Optional {
+		p.print('=')
+	}
+}
+
+func (p *printer) printNode(n Node) {
+	switch n := n.(type) {
+	case *Union:
+		for i, typ := range n.Types {
+			if i > 0 {
+				p.print('|')
+			}
+			p.print(typ)
+		}
+	case *Intersect:
+		for i, typ := range n.Types {
+			if i > 0 {
+				p.print('&')
+			}
+			p.print(typ)
+		}
+	case *Paren:
+		p.print('(', n.Type, ')')
+	case *Array:
+		p.print(n.Elem, "[]")
+	case *Nullable:
+		p.print('?', n.Type)
+	case *OffsetAccess:
+		p.print(n.Type, '[', n.Offset, ']')
+	case *Operator:
+		p.print(n.Op.String(), '<')
+		for i, typ := range n.Args {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(typ)
+		}
+		p.print('>')
+	case *Callable:
+		name := n.Name
+		if name == "" {
+			name = "callable"
+		}
+		p.print(name)
+		if len(n.TemplateParams) > 0 {
+			p.print('<')
+			for i, tp := range n.TemplateParams {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.print(tp)
+			}
+			p.print('>')
+		}
+		if len(n.Params) > 0 || n.Result != nil || len(n.TemplateParams) > 0 || name != "callable" {
+			p.print(n.Params)
+			if n.Result != nil {
+				p.print(": ", n.Result)
+			}
+		}
+	case *TypeParam:
+		if n.Wildcard {
+			p.print('*')
+			break
+		}
+		if n.Variance != Invariant {
+			p.print(n.Variance.String(), ' ')
+		}
+		p.print(n.Type)
+	case *TemplateParam:
+		p.print(n.Name)
+		if n.Bound != nil {
+			p.print(" of ", n.Bound)
+		}
+	case *Param:
+		p.print(n.Type)
+		if n.Name != "" {
+			p.print(' ')
+		}
+		p.printParamSuffix(n)
+	case *ArrayShape:
+		p.print(n.Kind.String())
+		if n.Elems == nil && !n.Unsealed && n.Kind == PlainArray {
+			break
+		}
+		p.print('{')
+		for i, elem := range n.Elems {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(elem)
+		}
+		if n.Unsealed {
+			if len(n.Elems) > 0 {
+				p.print(", ")
+			}
+			p.print("...")
+			if n.ExtraValue != nil {
+				p.print('<')
+				if n.ExtraKey != nil {
+					p.print(n.ExtraKey, ", ")
+				}
+				p.print(n.ExtraValue, '>')
+			}
+		}
+		p.print('}')
+	case *ArrayElem:
+		if n.Key != "" {
+			p.print(n.Key)
+			if n.Optional {
+				p.print('?')
+			}
+			p.print(": ")
+		}
+		p.print(n.Type)
+	case *ObjectShape:
+		p.print("object")
+		if len(n.Elems) == 0 {
+			break
+		}
+		p.print('{')
+		for i, elem := range n.Elems {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(elem)
+		}
+		p.print('}')
+	case *ObjectElem:
+		p.print(n.Key)
+		if n.Optional {
+			p.print('?')
+		}
+		p.print(": ", n.Type)
+	case *Generic:
+		p.print(n.Base, '<')
+		for i, tp := range n.TypeParams {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(tp)
+		}
+		p.print('>')
+	case *ConstFetch:
+		p.print(n.Class, "::", n.Name)
+	case *Literal:
+		p.print(n.Value)
+	case *IntRange:
+		p.print("int<")
+		p.printBound(n.Min, "min")
+		p.print(", ")
+		p.printBound(n.Max, "max")
+		p.print('>')
+	case *Named:
+		for i, part := range n.Parts {
+			if i > 0 || n.Global {
+				p.print('\\')
+			}
+			p.print(part)
+		}
+	case *This:
+		p.print("$this")
+	case *Conditional:
+		p.print('(')
+		if n.Param != "" {
+			p.print('$', n.Param)
+		} else {
+			p.print(n.Subject)
+		}
+		p.print(" is ")
+		if n.Negated {
+			p.print("not ")
+		}
+		p.print(n.Target, " ? ", n.If, " : ", n.Else, ')')
+	default:
+		panic(fmt.Sprintf("unknown PHP type node %T", n))
+	}
+}
+
+func (p *printer) printBound(b *int64, unbounded string) {
+	if b == nil {
+		p.print(unbounded)
+		return
+	}
+	p.print(strconv.FormatInt(*b, 10))
+}
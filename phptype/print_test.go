@@ -0,0 +1,70 @@
+package phptype_test
+
+import (
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{`? \ DateTime`, `?\DateTime`},
+		{`int [ ] | ( string &Foo )`, `int[]|(string&Foo)`},
+		{`array < string , array {0 ?:int , foo :\ Foo ,} >`, `array<string, array{0?: int, foo: \Foo}>`},
+		{`object { a :int , b ?: string}`, `object{a: int, b?: string}`},
+		{`list { int , ... < string > }|non-empty-list{int}`, `list{int, ...<string>}|non-empty-list{int}`},
+		{`array{ ... }|array{a: int, ...<int, string>,}`, `array{...}|array{a: int, ...<int, string>}`},
+		{`array { }|list{}|non-empty-array{ }|array`, `array{}|list{}|non-empty-array{}|array`},
+		{`callable ( int $a = 3 , string & ... $b ) : void`, `callable(int $a = 3, string &...$b): void`},
+		{`callable( ) :$this`, `callable(): $this`},
+		{`Closure( )|pure-Closure(int $x =)|callable < T , U of int > ( T ) : U`, `Closure()|pure-Closure(int $x=)|callable<T, U of int>(T): U`},
+		{`\Closure(int=, string = 'a'): void`, `\Closure(int=, string = 'a'): void`},
+		{`\Foo\Closure (int)|Closure < T >|callable<T>`, `\Foo\Closure(int)|Closure<T>|callable<T>`},
+		{`self :: ALL_*|'foo'|7`, `self::ALL_*|'foo'|7`},
+		{`new< T >|value-of<Foo::BAR_*>|int-mask-of < T[ 'flags' ] >`, `new<T>|value-of<Foo::BAR_*>|int-mask-of<T['flags']>`},
+		{`'a' | "b\"c" | -1 | 1_000 | 0x1F | 1.5e3`, `'a'|"b\"c"|-1|1_000|0x1F|1.5e3`},
+		{`Box < contravariant T , * , covariant >`, `Box<contravariant T, *, covariant>`},
+		{`int<-10,max>|int < min , 0 >`, `int<-10, max>|int<min, 0>`},
+		{`int<0, 1_000>|int<0x10, max>|int<-0x10, 0>`, `int<0, 1000>|int<16, max>|int<-16, 0>`},
+		{`( $x is not int?string:(T is X ? A : B))`, `($x is not int ? string : (T is X ? A : B))`},
+	}
+	for _, tt := range tests {
+		typ, err := phpdoc.ParseTypeString(tt.typ)
+		if err != nil {
+			t.Fatalf("%q: unexpected err: %v", tt.typ, err)
+		}
+		if got := typ.String(); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.typ, got, tt.want)
+		}
+	}
+
+	par := &phptype.Param{Type: &phptype.Named{Parts: []string{"int"}}, Variadic: true, Name: "xs"}
+	if got, want := par.String(), "int ...$xs"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParamSuffix(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want []string
+	}{
+		{`callable(int, int $a = 3, string &...$b)`, []string{"", "$a = 3", "&...$b"}},
+		{`callable(int=, int $x=, string = 'a')`, []string{"=", "$x=", " = 'a'"}},
+	}
+	for _, tt := range tests {
+		typ, err := phpdoc.ParseTypeString(tt.typ)
+		if err != nil {
+			t.Fatalf("%q: unexpected err: %v", tt.typ, err)
+		}
+		for i, par := range typ.(*phptype.Callable).Params {
+			if got := par.Suffix(); got != tt.want[i] {
+				t.Errorf("%q: param %d: got %q, want %q", tt.typ, i, got, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package phptype
+
+import "strings"
+
+// A NameKind describes what a Named type refers to.
+type NameKind int
+
+const (
+	ClassName    NameKind = iota // class, interface, trait, or enum
+	KeywordName                  // built-in type (e.g. int, list, non-empty-string)
+	TemplateName                 // template type or local type alias
+	ConstName                    // imported global constant
+)
+
+var nameKinds = [...]string{
+	ClassName:    "class",
+	KeywordName:  "keyword",
+	TemplateName: "template",
+	ConstName:    "const",
+}
+
+func (k NameKind) String() string { return nameKinds[k] }
+
+// keywords lists the built-in type names that are not class names.
+// The names are matched case-insensitively.
+var keywords = map[string]bool{
+	"array":                      true,
+	"array-key":                  true,
+	"bool":                       true,
+	"boolean":                    true,
+	"callable":                   true,
+	"callable-array":             true,
+	"callable-object":            true,
+	"callable-string":            true,
+	"class-string":               true,
+	"closed-resource":            true,
+	"double":                     true,
+	"empty":                      true,
+	"enum-string":                true,
+	"false":                      true,
+	"float":                      true,
+	"int":                        true,
+	"int-mask":                   true,
+	"int-mask-of":                true,
+	"integer":                    true,
+	"interface-string":           true,
+	"iterable":                   true,
+	"key-of":                     true,
+	"list":                       true,
+	"literal-int":                true,
+	"literal-string":             true,
+	"lowercase-string":           true,
+	"mixed":                      true,
+	"negative-int":               true,
+	"never":                      true,
+	"never-return":               true,
+	"never-returns":              true,
+	"no-return":                  true,
+	"non-empty-array":            true,
+	"non-empty-list":             true,
+	"non-empty-literal-string":   true,
+	"non-empty-lowercase-string": true,
+	"non-empty-mixed":            true,
+	"non-empty-scalar":           true,
+	"non-empty-string":           true,
+	"non-falsy-string":           true,
+	"non-negative-int":           true,
+	"non-positive-int":           true,
+	"non-zero-int":               true,
+	"noreturn":                   true,
+	"null":                       true,
+	"number":                     true,
+	"numeric":                    true,
+	"numeric-string":             true,
+	"object":                     true,
+	"open-resource":              true,
+	"parent":                     true,
+	"positive-int":               true,
+	"pure-callable":              true,
+	"resource":                   true,
+	"scalar":                     true,
+	"self":                       true,
+	"static":                     true,
+	"string":                     true,
+	"trait-string":               true,
+	"true":                       true,
+	"truthy-string":              true,
+	"value-of":                   true,
+	"void":                       true,
+}
+
+// IsKeyword reports whether name is a built-in type name, such as
+// int, list, or non-empty-string, rather than a class name.
+func IsKeyword(name string) bool {
+	return keywords[strings.ToLower(name)]
+}
+
+// A Scope describes the context in which the names used in PHP types
+// are resolved. All names are written without the leading backslash.
+type Scope struct {
+	Namespace string // current namespace, or "" for the global namespace
+
+	// Uses, FuncUses, and ConstUses map the aliases imported by the
+	// use, use function, and use const statements, respectively,
+	// to the fully qualified names.
+	Uses      map[string]string
+	FuncUses  map[string]string
+	ConstUses map[string]string
+
+	Templates []string // template types and local type aliases in scope
+}
+
+// Resolve returns the fully qualified name of n, including the leading
+// backslash, and the kind of the name. Names of the kinds KeywordName
+// and TemplateName are returned as they are.
+func (s *Scope) Resolve(n *Named) (name string, kind NameKind) {
+	name = strings.Join(n.Parts, `\`)
+	if n.Global {
+		return `\` + name, ClassName
+	}
+	if len(n.Parts) == 1 {
+		for _, t := range s.Templates {
+			if name == t {
+				return name, TemplateName
+			}
+		}
+		if IsKeyword(name) {
+			return name, KeywordName
+		}
+		if fqn, ok := s.ConstUses[name]; ok {
+			return `\` + fqn, ConstName
+		}
+	}
+	return s.resolveClass(n.Parts), ClassName
+}
+
+// ResolveFunc returns the fully qualified name of the function name.
+// Unqualified names that are not imported are resolved relative to
+// the current namespace, even though PHP falls back to the global
+// function at run time.
+func (s *Scope) ResolveFunc(name string) string {
+	return s.resolveFuncOrConst(name, s.FuncUses, strings.EqualFold)
+}
+
+// ResolveConst returns the fully qualified name of the constant name.
+// See ResolveFunc for the handling of unqualified names.
+func (s *Scope) ResolveConst(name string) string {
+	return s.resolveFuncOrConst(name, s.ConstUses, func(a, b string) bool { return a == b })
+}
+
+func (s *Scope) resolveFuncOrConst(name string, uses map[string]string, eq func(a, b string) bool) string {
+	if strings.HasPrefix(name, `\`) {
+		return name
+	}
+	parts := strings.Split(name, `\`)
+	if len(parts) > 1 {
+		return s.resolveClass(parts)
+	}
+	if fqn, ok := lookup(uses, name, eq); ok {
+		return `\` + fqn
+	}
+	return s.qualify(name)
+}
+
+// resolveClass resolves the qualified or unqualified class name
+// consisting of parts.
+func (s *Scope) resolveClass(parts []string) string {
+	if fqn, ok := lookup(s.Uses, parts[0], strings.EqualFold); ok {
+		return `\` + strings.Join(append([]string{fqn}, parts[1:]...), `\`)
+	}
+	if strings.EqualFold(parts[0], "namespace") && len(parts) > 1 {
+		parts = parts[1:]
+	}
+	return s.qualify(strings.Join(parts, `\`))
+}
+
+func (s *Scope) qualify(name string) string {
+	if s.Namespace == "" {
+		return `\` + name
+	}
+	return `\` + s.Namespace + `\` + name
+}
+
+func lookup(m map[string]string, key string, eq func(a, b string) bool) (string, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if eq(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package phptype_test
+
+import (
+	"testing"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestScopeResolve(t *testing.T) {
+	scope := &phptype.Scope{
+		Namespace: `App\Models`,
+		Uses: map[string]string{
+			"Collection": `Illuminate\Support\Collection`,
+			"Support":    `Illuminate\Support`,
+		},
+		ConstUses: map[string]string{"MAX": `App\Config\MAX`},
+		Templates: []string{"T"},
+	}
+	tests := []struct {
+		parts  []string
+		global bool
+		name   string
+		kind   phptype.NameKind
+	}{
+		{[]string{"Collection"}, false, `\Illuminate\Support\Collection`, phptype.ClassName},
+		{[]string{"collection"}, false, `\Illuminate\Support\Collection`, phptype.ClassName},
+		{[]string{"Support", "Str"}, false, `\Illuminate\Support\Str`, phptype.ClassName},
+		{[]string{"User"}, false, `\App\Models\User`, phptype.ClassName},
+		{[]string{"Sub", "User"}, false, `\App\Models\Sub\User`, phptype.ClassName},
+		{[]string{"namespace", "User"}, false, `\App\Models\User`, phptype.ClassName},
+		{[]string{"Collection"}, true, `\Collection`, phptype.ClassName},
+		{[]string{"T"}, false, "T", phptype.TemplateName},
+		{[]string{"t"}, false, `\App\Models\t`, phptype.ClassName},
+		{[]string{"int"}, false, "int", phptype.KeywordName},
+		{[]string{"Non-Empty-String"}, false, "Non-Empty-String", phptype.KeywordName},
+		{[]string{"self"}, false, "self", phptype.KeywordName},
+		{[]string{"MAX"}, false, `\App\Config\MAX`, phptype.ConstName},
+		{[]string{"Closure"}, false, `\App\Models\Closure`, phptype.ClassName},
+	}
+
+	for _, tt := range tests {
+		id := &phptype.Named{Parts: tt.parts, Global: tt.global}
+		name, kind := scope.Resolve(id)
+		if name != tt.name || kind != tt.kind {
+			t.Errorf("%s: got %s (%v), want %s (%v)", id, name, kind, tt.name, tt.kind)
+		}
+	}
+}
+
+func TestScopeResolveFunc(t *testing.T) {
+	scope := &phptype.Scope{
+		Namespace: "App",
+		Uses:      map[string]string{"Str": `Illuminate\Support\Str`},
+		FuncUses:  map[string]string{"collect": `Illuminate\Support\collect`},
+	}
+	tests := []struct{ name, want string }{
+		{"collect", `\Illuminate\Support\collect`},
+		{"Collect", `\Illuminate\Support\collect`},
+		{"strlen", `\App\strlen`},
+		{`\strlen`, `\strlen`},
+		{`Str\random`, `\Illuminate\Support\Str\random`},
+	}
+	for _, tt := range tests {
+		if got := scope.ResolveFunc(tt.name); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
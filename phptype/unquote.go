@@ -0,0 +1,105 @@
+package phptype
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var errQuote = errors.New("invalid quoted string")
+
+// Unquote interprets s as a single-quoted or double-quoted PHP string
+// literal, returning the string value that s quotes. The escape
+// sequences are decoded as PHP does it, including keeping unknown
+// sequences as they are. Variables in double-quoted strings are not
+// interpolated.
+func Unquote(s string) (string, error) {
+	n := len(s)
+	if n < 2 || s[0] != s[n-1] || s[0] != '\'' && s[0] != '"' {
+		return "", errQuote
+	}
+	quote := s[0]
+	s = s[1 : n-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			return "", errQuote
+		}
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		c = s[i]
+		if quote == '\'' {
+			if c != '\\' && c != '\'' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'v':
+			b.WriteByte('\v')
+		case 'f':
+			b.WriteByte('\f')
+		case 'e':
+			b.WriteByte(0x1b)
+		case '\\', '$', '"':
+			b.WriteByte(c)
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i + 1
+			for j < len(s) && j < i+3 && '0' <= s[j] && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 16)
+			b.WriteByte(byte(v))
+			i = j - 1
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				b.WriteString(`\x`)
+				break
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 16, 8)
+			b.WriteByte(byte(v))
+			i = j - 1
+		case 'u':
+			if i+1 == len(s) || s[i+1] != '{' {
+				b.WriteString(`\u`)
+				break
+			}
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", errQuote
+			}
+			v, err := strconv.ParseUint(s[i+2:i+end], 16, 32)
+			if err != nil || v > utf8.MaxRune {
+				return "", errQuote
+			}
+			b.WriteRune(rune(v))
+			i += end
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
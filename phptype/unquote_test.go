@@ -0,0 +1,36 @@
+package phptype_test
+
+import (
+	"testing"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{`'foo'`, "foo", true},
+		{`'it\'s \n \\'`, `it's \n \`, true},
+		{`"a\tb\n"`, "a\tb\n", true},
+		{`"\$x \"y\" \\"`, `$x "y" \`, true},
+		{`"\101\x42\x4"`, "AB\x04", true},
+		{`"\u{1F600} \u \x \q"`, "\U0001F600 \\u \\x \\q", true},
+		{`"\u{zz}"`, "", false},
+		{`"\u{41"`, "", false},
+		{`'foo"`, "", false},
+		{`"a"b"`, "", false},
+		{`foo`, "", false},
+	}
+	for _, tt := range tests {
+		got, err := phptype.Unquote(tt.in)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("%s: got err %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package phptype
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by
+// Walk. If the result visitor w is not nil, Walk visits each of the
+// children of node with the visitor w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a PHP type syntax tree in depth-first order. It
+// starts by calling v.Visit(node); node must not be nil. If the
+// visitor w returned by v.Visit(node) is not nil, Walk is invoked
+// recursively with visitor w for each of the non-nil children of node,
+// followed by a call of w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Union:
+		walkTypeList(v, n.Types)
+	case *Intersect:
+		walkTypeList(v, n.Types)
+	case *Paren:
+		Walk(v, n.Type)
+	case *Array:
+		Walk(v, n.Elem)
+	case *Nullable:
+		Walk(v, n.Type)
+	case *OffsetAccess:
+		Walk(v, n.Type)
+		Walk(v, n.Offset)
+	case *Operator:
+		walkTypeList(v, n.Args)
+	case *ArrayShape:
+		for _, elem := range n.Elems {
+			Walk(v, elem)
+		}
+		if n.ExtraKey != nil {
+			Walk(v, n.ExtraKey)
+		}
+		if n.ExtraValue != nil {
+			Walk(v, n.ExtraValue)
+		}
+	case *ArrayElem:
+		Walk(v, n.Type)
+	case *ObjectShape:
+		for _, elem := range n.Elems {
+			Walk(v, elem)
+		}
+	case *ObjectElem:
+		Walk(v, n.Type)
+	case *Generic:
+		Walk(v, n.Base)
+		for _, tp := range n.TypeParams {
+			Walk(v, tp)
+		}
+	case *TypeParam:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *ConstFetch:
+		Walk(v, n.Class)
+	case *Literal, *IntRange, *Named, *This:
+		// Nothing to do.
+	case *Param:
+		Walk(v, n.Type)
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+	case *TemplateParam:
+		if n.Bound != nil {
+			Walk(v, n.Bound)
+		}
+	case *Callable:
+		for _, tp := range n.TemplateParams {
+			Walk(v, tp)
+		}
+		for _, par := range n.Params {
+			Walk(v, par)
+		}
+		if n.Result != nil {
+			Walk(v, n.Result)
+		}
+	case *Conditional:
+		if n.Subject != nil {
+			Walk(v, n.Subject)
+		}
+		Walk(v, n.Target)
+		Walk(v, n.If)
+		Walk(v, n.Else)
+	default:
+		panic(fmt.Sprintf("phptype.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkTypeList(v Visitor, list []Type) {
+	for _, typ := range list {
+		Walk(v, typ)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a PHP type syntax tree in depth-first order. It
+// starts by calling f(node); node must not be nil. If f returns true,
+// Inspect invokes f recursively for each of the non-nil children of
+// node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,63 @@
+package phptype_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestInspect(t *testing.T) {
+	typ, err := phpdoc.ParseTypeString(`array<int, Foo\Bar|?Baz[]>|object{a: C::D}|callable(Qux $q = 3): (A&B)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	phptype.Inspect(typ, func(n phptype.Node) bool {
+		switch n := n.(type) {
+		case *phptype.Named:
+			names = append(names, n.String())
+		case *phptype.Callable:
+			// Skip the params.
+			names = append(names, n.Result.String())
+			return false
+		}
+		return true
+	})
+	got := strings.Join(names, " ")
+	const want = `int Foo\Bar Baz C (A&B)`
+	if got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+type countVisitor map[string]int
+
+func (c countVisitor) Visit(n phptype.Node) phptype.Visitor {
+	c[fmt.Sprintf("%T", n)]++
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	typ, err := phpdoc.ParseTypeString(`array{a: int, 'b'?: string}|\Foo<int>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := make(countVisitor)
+	phptype.Walk(c, typ)
+	want := countVisitor{
+		"*phptype.Union":      1,
+		"*phptype.ArrayShape": 1,
+		"*phptype.ArrayElem":  2,
+		"*phptype.Named":      4,
+		"*phptype.Generic":    1,
+		"*phptype.TypeParam":  1,
+		"<nil>":               10,
+	}
+	if fmt.Sprint(c) != fmt.Sprint(want) {
+		t.Errorf("\n got %v\nwant %v", c, want)
+	}
+}
@@ -2,20 +2,72 @@ package phpdoc
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
 	"mibk.dev/phpdoc/internal/token"
 	"mibk.dev/phpdoc/phptype"
 )
 
-// Fprint "pretty-prints" an AST node to w.
+// A Config controls the output of Fprint. The zero Config
+// corresponds to the default formatting.
+type Config struct {
+	// Padding is the minimal number of spaces between aligned
+	// columns. Zero means 1.
+	Padding int
+
+	// NoAlign disables the alignment of tag columns. The tag name,
+	// type, variable name, and description are separated by
+	// a single space.
+	NoAlign bool
+
+	// NoTypeAlign limits the alignment to tag names. The types,
+	// variable names, and descriptions (e.g. of @param tags) are
+	// separated by a single space.
+	NoTypeAlign bool
+
+	// Oneline controls which blocks are printed on a single line.
+	Oneline OnelineMode
+
+	// TemplateAs makes the printer use "as" instead of "of" for
+	// @template bounds.
+	TemplateAs bool
+}
+
+// An OnelineMode controls which blocks are printed on a single line,
+// e.g. /** @var int */.
+type OnelineMode int
+
+const (
+	// OnelinePreserve collapses blocks with a single line that
+	// were written on a single line (see Block.PreferOneline).
+	OnelinePreserve OnelineMode = iota
+
+	// OnelineAlways collapses all blocks with a single line.
+	OnelineAlways
+
+	// OnelineNever never collapses blocks.
+	OnelineNever
+)
+
+// Fprint "pretty-prints" an AST node to w using the default Config.
 func Fprint(w io.Writer, node interface{}) error {
-	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.StripEscape)
+	return new(Config).Fprint(w, node)
+}
+
+// Fprint "pretty-prints" an AST node to w.
+func (cfg *Config) Fprint(w io.Writer, node interface{}) error {
+	padding := cfg.Padding
+	if padding <= 0 {
+		padding = 1
+	}
+	tw := tabwriter.NewWriter(&trimmer{w: w}, 0, 0, padding, ' ', tabwriter.StripEscape)
 	buf := bufio.NewWriter(tw)
-	p := &printer{buf: buf}
+	p := &printer{Config: cfg, buf: buf}
 	p.print(node)
 	if p.err != nil {
 		return p.err
@@ -26,9 +78,61 @@ func Fprint(w io.Writer, node interface{}) error {
 	return tw.Flush()
 }
 
+// A trimmer is an io.Writer that drops trailing spaces of lines, such
+// as the padding of a tag whose description starts on the next line.
+type trimmer struct {
+	w      io.Writer
+	spaces int // pending spaces
+}
+
+func (t *trimmer) Write(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, " \n")
+		if i < 0 {
+			i = len(b)
+		}
+		if i > 0 {
+			if err := t.flush(b[:i]); err != nil {
+				return n, err
+			}
+			n += i
+			b = b[i:]
+			continue
+		}
+		if b[0] == ' ' {
+			t.spaces++
+		} else {
+			t.spaces = 0
+			if _, err := t.w.Write(b[:1]); err != nil {
+				return n, err
+			}
+		}
+		n++
+		b = b[1:]
+	}
+	return n, nil
+}
+
+// flush writes the pending spaces, followed by text.
+func (t *trimmer) flush(text []byte) error {
+	if t.spaces > 0 {
+		if _, err := io.WriteString(t.w, strings.Repeat(" ", t.spaces)); err != nil {
+			return err
+		}
+		t.spaces = 0
+	}
+	_, err := t.w.Write(text)
+	return err
+}
+
 type printer struct {
+	*Config
 	buf *bufio.Writer
 	err error // sticky
+
+	cols   int    // number of columns on the current line
+	width  int    // width of the current cell
+	indent string // indentation of the current block
 }
 
 type whitespace byte
@@ -47,8 +151,9 @@ func (p *printer) print(args ...interface{}) {
 
 		switch arg := arg.(type) {
 		case *Block:
+			p.indent = arg.Indent
 			p.print(tabesc, arg.Indent, tabesc, token.OpenDoc)
-			if arg.PreferOneline && len(arg.Lines) == 1 {
+			if p.oneline(arg) {
 				p.print(arg.Lines[0])
 			} else {
 				p.print(newline)
@@ -61,38 +166,42 @@ func (p *printer) print(args ...interface{}) {
 		case Line:
 			p.printLine(arg)
 		case phptype.Type:
-			p.printPHPType(arg)
+			p.print(tabesc, arg.String(), tabesc)
 		case []*phptype.Param:
 			p.print(token.Lparen)
 			for i, par := range arg {
 				if i > 0 {
 					p.print(token.Comma, ' ')
 				}
-				p.print(par.Type)
-				if par.Name != "" {
-					p.print(' ', par)
-				}
+				p.print(tabesc, par.String(), tabesc)
 			}
 			p.print(token.Rparen)
 		case *phptype.Param:
 			// The type is printed by the owner.
-			if arg.ByRef {
-				p.print(token.And)
-			}
-			if arg.Variadic {
-				p.print(token.Ellipsis)
-			}
-			p.print('$', arg.Name)
-			if arg.Default != nil {
-				p.print(' ', token.Assign, ' ', arg.Default)
-			}
+			p.print(tabesc, arg.Suffix(), tabesc)
 		case token.Type:
+			p.width += utf8.RuneCountInString(arg.String())
 			_, p.err = p.buf.WriteString(arg.String())
 		case string:
+			p.width += utf8.RuneCountInString(arg)
 			_, p.err = p.buf.WriteString(arg)
 		case rune:
+			p.width++
 			_, p.err = p.buf.WriteRune(arg)
 		case whitespace:
+			switch arg {
+			case nextcol:
+				if p.NoAlign || p.NoTypeAlign && p.cols > 0 {
+					arg = ' '
+					p.width++
+				} else {
+					p.width = 0
+				}
+				p.cols++
+			case newline:
+				p.cols = 0
+				p.width = 0
+			}
 			p.err = p.buf.WriteByte(byte(arg))
 		default:
 			p.err = fmt.Errorf("unsupported type %T", arg)
@@ -100,6 +209,20 @@ func (p *printer) print(args ...interface{}) {
 	}
 }
 
+func (p *printer) oneline(b *Block) bool {
+	if len(b.Lines) != 1 || strings.Contains(lineDesc(b.Lines[0]), "\n") {
+		return false
+	}
+	switch p.Oneline {
+	case OnelineAlways:
+		return true
+	case OnelineNever:
+		return false
+	default:
+		return b.PreferOneline
+	}
+}
+
 func (p *printer) printLine(line Line) {
 	switch l := line.(type) {
 	case *TextLine:
@@ -120,11 +243,11 @@ func (p *printer) printLine(line Line) {
 func (p *printer) printTag(tag Tag) {
 	switch tag := tag.(type) {
 	case *ParamTag:
-		p.print("@param", nextcol, tag.Param.Type, nextcol, tag.Param)
+		p.print(tagName(tag.Vendor, "param"), nextcol, tag.Param.Type, nextcol, tag.Param)
 	case *ReturnTag:
-		p.print("@return", nextcol, tag.Type)
+		p.print(tagName(tag.Vendor, "return"), nextcol, tag.Type)
 	case *PropertyTag:
-		p.print("@property")
+		p.print(tagName(tag.Vendor, "property"))
 		switch {
 		case tag.ReadOnly && tag.WriteOnly:
 			// Impossible, but…
@@ -135,7 +258,7 @@ func (p *printer) printTag(tag Tag) {
 		}
 		p.print(nextcol, tag.Type, nextcol, '$', tag.Var)
 	case *MethodTag:
-		p.print("@method", nextcol)
+		p.print(tagName(tag.Vendor, "method"), nextcol)
 		if tag.Static {
 			p.print(token.Static, ' ')
 		}
@@ -144,125 +267,124 @@ func (p *printer) printTag(tag Tag) {
 		}
 		p.print(tag.Name, tag.Params)
 	case *VarTag:
-		p.print("@var", nextcol, tag.Type)
+		p.print(tagName(tag.Vendor, "var"), nextcol, tag.Type)
 		if tag.Var != "" {
 			p.print(nextcol, '$', tag.Var)
 		}
 	case *ThrowsTag:
-		p.print("@throws", nextcol, tag.Class)
+		p.print(tagName(tag.Vendor, "throws"), nextcol, tag.Class)
 	case *ExtendsTag:
-		p.print("@extends", nextcol, tag.Class)
+		p.print(tagName(tag.Vendor, "extends"), nextcol, tag.Class)
 	case *ImplementsTag:
-		p.print("@implements", nextcol, tag.Interface)
+		p.print(tagName(tag.Vendor, "implements"), nextcol, tag.Interface)
 	case *UsesTag:
-		p.print("@uses", nextcol, tag.Trait)
+		p.print(tagName(tag.Vendor, "uses"), nextcol, tag.Trait)
 	case *TemplateTag:
-		p.print("@template", nextcol, tag.Param)
+		name := "template"
+		if tag.Variance != phptype.Invariant {
+			name += "-" + tag.Variance.String()
+		}
+		p.print(tagName(tag.Vendor, name), nextcol, tag.Param)
 		if tag.Bound != nil {
-			p.print(" of ", tag.Bound)
+			kw := "of"
+			if p.TemplateAs {
+				kw = "as"
+			}
+			p.print(' ', kw, ' ', tag.Bound)
+		}
+		if tag.Default != nil {
+			p.print(' ', token.Assign, ' ', tag.Default)
 		}
 	case *TypeDefTag:
-		p.print("@phpstan-type", nextcol, tag.Name, nextcol, tag.Type)
+		p.print(tagName(tag.Vendor, "type"), nextcol, tag.Name, nextcol)
+		if tag.Vendor == "psalm" {
+			// Psalm requires the equals sign.
+			p.print(token.Assign, ' ')
+		}
+		p.print(tag.Type)
+	case *ImportTypeTag:
+		p.print(tagName(tag.Vendor, "import-type"), nextcol, tag.Name, " from ", tag.From)
+		if tag.Alias != "" {
+			p.print(" as ", tag.Alias)
+		}
+	case *MixinTag:
+		p.print(tagName(tag.Vendor, "mixin"), nextcol, tag.Class)
+	case *ParamOutTag:
+		p.print(tagName(tag.Vendor, "param-out"), nextcol, tag.Type, nextcol, '$', tag.Var)
+	case *AssertTag:
+		name := "assert"
+		switch {
+		case tag.IfTrue && tag.IfFalse:
+			// Impossible, but…
+		case tag.IfTrue:
+			name += "-if-true"
+		case tag.IfFalse:
+			name += "-if-false"
+		}
+		p.print(tagName(tag.Vendor, name), nextcol)
+		if tag.Negated {
+			p.print('!')
+		}
+		if tag.Equal {
+			p.print(token.Assign)
+		}
+		p.print(tag.Type, nextcol, '$', tag.Var)
+	case *SelfOutTag:
+		p.print(tagName(tag.Vendor, "self-out"), nextcol, tag.Type)
+	case *FlagTag:
+		p.print(tagName(tag.Vendor, tag.Name))
+	case *DeprecatedTag:
+		p.print("@deprecated")
+		p.printWord(tag.Version)
+	case *SinceTag:
+		p.print("@since")
+		p.printWord(tag.Version)
+	case *VersionTag:
+		p.print("@version")
+		p.printWord(tag.Version)
+	case *SeeTag:
+		p.print("@see")
+		p.printWord(tag.Ref)
+	case *LinkTag:
+		p.print("@link")
+		p.printWord(tag.URI)
+	case *BadTag:
+		p.print(tabesc, tag.Text, tabesc)
 	case *OtherTag:
 		p.print('@', tag.Name)
 	default:
 		panic(fmt.Sprintf("unknown tag line %T", tag))
 	}
 	if desc := tag.desc(); desc != "" {
-		p.print(nextcol, tabesc, desc, tabesc)
+		// Indent the continuation lines under the description
+		// using empty cells, padded with spaces to the width
+		// of the unaligned part of the tag.
+		lines := strings.Split(desc, "\n")
+		p.print(nextcol)
+		cols, width := p.cols, p.width
+		p.print(tabesc, lines[0], tabesc)
+		for _, line := range lines[1:] {
+			p.print(newline, tabesc, p.indent, tabesc, " * ")
+			for i := 0; i < cols; i++ {
+				p.print(nextcol)
+			}
+			p.print(strings.Repeat(" ", width-p.width), tabesc, line, tabesc)
+		}
 	}
 }
 
-func (p *printer) printPHPType(typ phptype.Type) {
-	switch typ := typ.(type) {
-	case *phptype.Union:
-		for i, typ := range typ.Types {
-			if i > 0 {
-				p.print(token.Or)
-			}
-			p.print(typ)
-		}
-	case *phptype.Intersect:
-		for i, typ := range typ.Types {
-			if i > 0 {
-				p.print(token.And)
-			}
-			p.print(typ)
-		}
-	case *phptype.Paren:
-		p.print(token.Lparen, typ.Type, token.Rparen)
-	case *phptype.Array:
-		p.print(typ.Elem, token.Lbrack, token.Rbrack)
-	case *phptype.Nullable:
-		p.print(token.Qmark, typ.Type)
-	case *phptype.Callable:
-		p.print(token.Callable)
-		if len(typ.Params) > 0 || typ.Result != nil {
-			p.print(typ.Params)
-			if typ.Result != nil {
-				p.print(token.Colon, ' ', typ.Result)
-			}
-		}
-	case *phptype.ArrayShape:
-		p.print(token.Array)
-		if len(typ.Elems) == 0 {
-			break
-		}
-		p.print(token.Lbrace)
-		for i, elem := range typ.Elems {
-			if i > 0 {
-				p.print(token.Comma, ' ')
-			}
-			if elem.Key != "" {
-				p.print(elem.Key)
-				if elem.Optional {
-					p.print(token.Qmark)
-				}
-				p.print(token.Colon, ' ')
-			}
-			p.print(elem.Type)
-		}
-		p.print(token.Rbrace)
-	case *phptype.ObjectShape:
-		p.print(token.Object)
-		if len(typ.Elems) == 0 {
-			break
-		}
-		p.print(token.Lbrace)
-		for i, elem := range typ.Elems {
-			if i > 0 {
-				p.print(token.Comma, ' ')
-			}
-			p.print(elem.Key)
-			if elem.Optional {
-				p.print(token.Qmark)
-			}
-			p.print(token.Colon, ' ', elem.Type)
-		}
-		p.print(token.Rbrace)
-	case *phptype.Generic:
-		p.print(typ.Base, token.Lt)
-		for i, typ := range typ.TypeParams {
-			if i > 0 {
-				p.print(token.Comma, ' ')
-			}
-			p.print(typ)
-		}
-		p.print(token.Gt)
-	case *phptype.ConstFetch:
-		p.print(typ.Class, token.DoubleColon, typ.Name)
-	case *phptype.Literal:
-		p.print(typ.Value)
-	case *phptype.Named:
-		for i, part := range typ.Parts {
-			if i > 0 || typ.Global {
-				p.print(token.Backslash)
-			}
-			p.print(part)
-		}
-	case *phptype.This:
-		p.print(token.This)
-	default:
-		panic(fmt.Sprintf("unknown PHP type %T", typ))
+// printWord prints s in the next column, unless s is empty.
+func (p *printer) printWord(s string) {
+	if s != "" {
+		p.print(nextcol, tabesc, s, tabesc)
+	}
+}
+
+// tagName returns the name of a tag, including the @ sign and the
+// vendor prefix, if any.
+func tagName(vendor, name string) string {
+	if vendor != "" {
+		return "@" + vendor + "-" + name
 	}
+	return "@" + name
 }
@@ -208,6 +208,176 @@ It's	deprecated now.
 /**
  * @param 'foo'|7|'bar'[] $xyz
  */
+`},
+	{"conditional return type", `
+/**
+@template T
+@param T $value
+@return ( T is  int|float ?string:  bool )
+*/
+----
+/**
+ * @template T
+ * @param    T $value
+ * @return   (T is int|float ? string : bool)
+ */
+`},
+	{"template variants", `
+/**
+@template-covariant TKey of array-key
+@phpstan-template T of object = \stdClass The object
+@psalm-template-contravariant U=int
+*/
+----
+/**
+ * @template-covariant           TKey of array-key
+ * @phpstan-template             T of object = \stdClass The object
+ * @psalm-template-contravariant U = int
+ */
+`},
+	{"vendor tags", `
+/**
+@param int $x
+@phpstan-param positive-int $x
+@psalm-return list<int>
+@phan-var int $y
+@phpstan-property-read int $z
+@psalm-method static list<int> foo(int $a)
+@phpstan-ignore-next-line
+*/
+----
+/**
+ * @param                 int          $x
+ * @phpstan-param         positive-int $x
+ * @psalm-return          list<int>
+ * @phan-var              int $y
+ * @phpstan-property-read int $z
+ * @psalm-method          static list<int> foo(int $a)
+ * @phpstan-ignore-next-line
+ */
+`},
+	{"multi-line descriptions", `
+	/**
+	 * Summary.
+	 *   Not a continuation.
+	 * @param array $opts Options:
+	 *   - foo
+	 *     more foo
+	 *  - bar
+	 * @param int $x
+	 *   Continued.
+	 * @return bool True
+	 *             if the options are valid.
+	 */
+----
+	/**
+	 * Summary.
+	 *   Not a continuation.
+	 * @param  array $opts Options:
+	 *                     - foo
+	 *                       more foo
+	 *                     - bar
+	 * @param  int   $x
+	 *                     Continued.
+	 * @return bool  True
+	 *               if the options are valid.
+	 */
+`},
+	{"multi-line oneline", `
+/** @var int Foo
+      bar */
+----
+/**
+ * @var int Foo
+ *          bar
+ */
+`},
+	{"int ranges", `
+/**
+@param int<0x10,1_000> $a
+@return int<-0o17, max>
+*/
+----
+/**
+ * @param  int<16, 1000> $a
+ * @return int<-15, max>
+ */
+`},
+	{"spaced brackets", `
+/**
+@param  string [ ]  $a
+@return string [optional] desc
+*/
+----
+/**
+ * @param  string[] $a
+ * @return string   [optional] desc
+ */
+`},
+	{"optional method params", `
+/**
+@method  foo( int = , string $s= , int ...$x )
+*/
+----
+/**
+ * @method foo(int=, string $s=, int ...$x)
+ */
+`},
+	{"assertions", `
+/**
+@mixin   \ Foo
+@param-out  int|null  $x  The x
+@phpstan-assert-if-true  string $value
+@psalm-assert ! null $x
+@psalm-assert-if-false =  list<int>$y
+@phpstan-self-out static < T >
+*/
+----
+/**
+ * @mixin                  \Foo
+ * @param-out              int|null   $x The x
+ * @phpstan-assert-if-true string     $value
+ * @psalm-assert           !null      $x
+ * @psalm-assert-if-false  =list<int> $y
+ * @phpstan-self-out       static<T>
+ */
+`},
+	{"references", `
+/**
+@since   2.1
+@deprecated	3.0   Use bar() instead.
+@see   Foo::bar()
+@see https://example.com  Docs
+@link https://example.com/a?b=c#x
+*/
+----
+/**
+ * @since      2.1
+ * @deprecated 3.0 Use bar() instead.
+ * @see        Foo::bar()
+ * @see        https://example.com Docs
+ * @link       https://example.com/a?b=c#x
+ */
+`},
+	{"type aliases", `
+/**
+@psalm-type UserId=int
+@phpstan-type   Row  =  array{id: UserId}  A row
+@phpstan-import-type Row from \App\Repo as RepoRow
+@psalm-import-type UserId  from Users
+*/
+----
+/**
+ * @psalm-type          UserId = int
+ * @phpstan-type        Row    array{id: UserId} A row
+ * @phpstan-import-type Row from \App\Repo as RepoRow
+ * @psalm-import-type   UserId from Users
+ */
+`},
+	{"generic wildcard", `
+/** @return Foo<*>*/
+----
+/** @return Foo<*> */
 `},
 	{"non-doc comment", `
 /** *********** */
@@ -258,14 +428,136 @@ func TestPrinting(t *testing.T) {
 	}
 }
 
+var configTests = []struct {
+	name string
+	cfg  phpdoc.Config
+	test string
+}{
+	{"padding", phpdoc.Config{Padding: 2}, `
+/**
+@param int $a A
+@param string $bb B
+@return bool
+*/
+----
+/**
+ * @param   int     $a   A
+ * @param   string  $bb  B
+ * @return  bool
+ */
+`},
+	{"no align", phpdoc.Config{NoAlign: true}, `
+/**
+@param int $a A
+@param string $bb B
+@return bool
+*/
+----
+/**
+ * @param int $a A
+ * @param string $bb B
+ * @return bool
+ */
+`},
+	{"no align continuation", phpdoc.Config{NoAlign: true}, `
+/**
+@param int $a A
+         continued
+*/
+----
+/**
+ * @param int $a A
+ *               continued
+ */
+`},
+	{"no align next line", phpdoc.Config{NoAlign: true}, `
+/**
+@param int $a
+   The a.
+*/
+----
+/**
+ * @param int $a
+ *               The a.
+ */
+`},
+	{"no type align continuation", phpdoc.Config{NoTypeAlign: true}, `
+/**
+@param int $čas Čas
+         pokračuje
+@return bool
+*/
+----
+/**
+ * @param  int $čas Čas
+ *                  pokračuje
+ * @return bool
+ */
+`},
+	{"no type align", phpdoc.Config{NoTypeAlign: true}, `
+/**
+@param int $a A
+@param string $bb B
+@return bool
+*/
+----
+/**
+ * @param  int $a A
+ * @param  string $bb B
+ * @return bool
+ */
+`},
+	{"oneline always", phpdoc.Config{Oneline: phpdoc.OnelineAlways}, `
+/**
+ * @var int
+ */
+----
+/** @var int */
+`},
+	{"oneline never", phpdoc.Config{Oneline: phpdoc.OnelineNever}, `
+/** @var int */
+----
+/**
+ * @var int
+ */
+`},
+	{"template as", phpdoc.Config{TemplateAs: true}, `
+/**
+@template T of \Countable
+*/
+----
+/**
+ * @template T as \Countable
+ */
+`},
+}
+
+func TestConfig(t *testing.T) {
+	for _, tt := range configTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := strings.Split(tt.test, "----\n")
+			if len(s) != 2 {
+				t.Fatal("invalid test format")
+			}
+
+			input, want := s[0], s[1]
+			configTestCase(t, &tt.cfg, input, want)
+		})
+	}
+}
+
 func printerTestCase(t *testing.T, input, want string) {
+	configTestCase(t, new(phpdoc.Config), input, want)
+}
+
+func configTestCase(t *testing.T, cfg *phpdoc.Config, input, want string) {
 	doc, err := phpdoc.Parse(strings.NewReader(input))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	got := new(strings.Builder)
-	if err := phpdoc.Fprint(got, doc); err != nil {
+	if err := cfg.Fprint(got, doc); err != nil {
 		t.Fatalf("printing: unexpected err: %v", err)
 	}
 	if got.String() != want {
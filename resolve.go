@@ -0,0 +1,77 @@
+package phpdoc
+
+import (
+	"strings"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+// ResolveNames rewrites every class name in the block b to its fully
+// qualified form according to the scope s. The template types and the
+// local type aliases declared in b are added to the scope; they are
+// left untouched, as are the built-in type names.
+func ResolveNames(b *Block, s *phptype.Scope) {
+	scope := *s
+	scope.Templates = append([]string(nil), s.Templates...)
+	for _, line := range b.Lines {
+		switch l := line.(type) {
+		case *TemplateTag:
+			scope.Templates = append(scope.Templates, l.Param)
+		case *TypeDefTag:
+			scope.Templates = append(scope.Templates, l.Name)
+		case *ImportTypeTag:
+			name := l.Name
+			if l.Alias != "" {
+				name = l.Alias
+			}
+			scope.Templates = append(scope.Templates, name)
+		}
+	}
+
+	Inspect(b, func(n Node) bool {
+		switch n.(type) {
+		case nil, *Block, Line:
+			return true
+		}
+		// A PHP type node.
+		resolveTypeNames(n, &scope)
+		return false
+	})
+}
+
+func resolveTypeNames(n phptype.Node, s *phptype.Scope) {
+	phptype.Inspect(n, func(n phptype.Node) bool {
+		switch n := n.(type) {
+		case *phptype.Named:
+			switch name, kind := s.Resolve(n); kind {
+			case phptype.ClassName, phptype.ConstName:
+				n.Parts = strings.Split(name[1:], `\`)
+				n.Global = true
+			}
+		case *phptype.Callable:
+			if len(n.TemplateParams) == 0 {
+				break
+			}
+			// The template params are in scope within the whole
+			// callable type, including their bounds.
+			inner := *s
+			inner.Templates = append([]string(nil), s.Templates...)
+			for _, tp := range n.TemplateParams {
+				inner.Templates = append(inner.Templates, tp.Name)
+			}
+			for _, tp := range n.TemplateParams {
+				if tp.Bound != nil {
+					resolveTypeNames(tp.Bound, &inner)
+				}
+			}
+			for _, par := range n.Params {
+				resolveTypeNames(par, &inner)
+			}
+			if n.Result != nil {
+				resolveTypeNames(n.Result, &inner)
+			}
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,52 @@
+package phpdoc_test
+
+import (
+	"strings"
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestResolveNames(t *testing.T) {
+	const doc = `/**
+ * @template T of Model
+ * @phpstan-type Row array{id: int, user: User}
+ * @param Collection<int, T> $items
+ * @param Row|static::FOO $row
+ * @return non-empty-list<\Foo|Bar\Baz>
+ * @var Closure<U of Model>(U, T): U
+ * @phpstan-import-type Item from Repo as RepoItem
+ * @param RepoItem $item
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	phpdoc.ResolveNames(block, &phptype.Scope{
+		Namespace: "App",
+		Uses: map[string]string{
+			"Collection": `Illuminate\Support\Collection`,
+			"Model":      `Illuminate\Database\Eloquent\Model`,
+		},
+	})
+
+	var buf strings.Builder
+	if err := phpdoc.Fprint(&buf, block); err != nil {
+		t.Fatal(err)
+	}
+	const want = `/**
+ * @template            T of \Illuminate\Database\Eloquent\Model
+ * @phpstan-type        Row                                    array{id: int, user: \App\User}
+ * @param               \Illuminate\Support\Collection<int, T> $items
+ * @param               Row|static::FOO                        $row
+ * @return              non-empty-list<\Foo|\App\Bar\Baz>
+ * @var                 Closure<U of \Illuminate\Database\Eloquent\Model>(U, T): U
+ * @phpstan-import-type Item from \App\Repo as RepoItem
+ * @param               RepoItem $item
+ */
+`
+	if got := buf.String(); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
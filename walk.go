@@ -0,0 +1,155 @@
+package phpdoc
+
+import (
+	"fmt"
+
+	"mibk.dev/phpdoc/phptype"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by
+// Walk. If the result visitor w is not nil, Walk visits each of the
+// children of node with the visitor w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a PHPDoc syntax tree in depth-first order, including
+// the PHP types. It starts by calling v.Visit(node); node must not be
+// nil. If the visitor w returned by v.Visit(node) is not nil, Walk is
+// invoked recursively with visitor w for each of the non-nil children
+// of node, followed by a call of w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	switch node.(type) {
+	case *Comment, *Block, Line, *Description, DescPart:
+	default:
+		// A PHP type node.
+		phptype.Walk(typeVisitor{v}, node)
+		return
+	}
+
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Comment:
+		if n.Block != nil {
+			Walk(v, n.Block)
+		}
+	case *Block:
+		for _, line := range n.Lines {
+			Walk(v, line)
+		}
+	case *Description:
+		for _, part := range n.Parts {
+			Walk(v, part)
+		}
+	case *DescText, *InlineTag:
+		// Nothing to do.
+	case *TextLine, *FlagTag, *DeprecatedTag, *SinceTag, *VersionTag, *SeeTag, *LinkTag, *BadTag, *OtherTag:
+		// Nothing to do.
+	case *ParamTag:
+		if n.Param != nil {
+			Walk(v, n.Param)
+		}
+	case *ReturnTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *PropertyTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *MethodTag:
+		if n.Result != nil {
+			Walk(v, n.Result)
+		}
+		for _, par := range n.Params {
+			Walk(v, par)
+		}
+	case *VarTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *ThrowsTag:
+		if n.Class != nil {
+			Walk(v, n.Class)
+		}
+	case *ExtendsTag:
+		if n.Class != nil {
+			Walk(v, n.Class)
+		}
+	case *ImplementsTag:
+		if n.Interface != nil {
+			Walk(v, n.Interface)
+		}
+	case *UsesTag:
+		if n.Trait != nil {
+			Walk(v, n.Trait)
+		}
+	case *TemplateTag:
+		if n.Bound != nil {
+			Walk(v, n.Bound)
+		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+	case *TypeDefTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *ImportTypeTag:
+		if n.From != nil {
+			Walk(v, n.From)
+		}
+	case *MixinTag:
+		if n.Class != nil {
+			Walk(v, n.Class)
+		}
+	case *ParamOutTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *AssertTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	case *SelfOutTag:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+	default:
+		panic(fmt.Sprintf("phpdoc.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// typeVisitor adapts a Visitor for walking PHP type syntax trees.
+type typeVisitor struct{ v Visitor }
+
+func (tv typeVisitor) Visit(node phptype.Node) phptype.Visitor {
+	w := tv.v.Visit(node)
+	if w == nil {
+		return nil
+	}
+	return typeVisitor{w}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a PHPDoc syntax tree in depth-first order. It
+// starts by calling f(node); node must not be nil. If f returns true,
+// Inspect invokes f recursively for each of the non-nil children of
+// node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
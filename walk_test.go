@@ -0,0 +1,75 @@
+package phpdoc_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mibk.dev/phpdoc"
+	"mibk.dev/phpdoc/phptype"
+)
+
+func TestInspect(t *testing.T) {
+	const doc = `/**
+ * Text.
+ * @template T of \Countable
+ * @param T|null $a
+ * @method static Foo bar(int $x)
+ * @author Bob
+ */`
+	block, err := phpdoc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []string
+	phpdoc.Inspect(block, func(n phpdoc.Node) bool {
+		switch n := n.(type) {
+		case nil:
+		case *phptype.Named:
+			nodes = append(nodes, n.String())
+		default:
+			nodes = append(nodes, strings.TrimPrefix(fmt.Sprintf("%T", n), "*"))
+		}
+		return true
+	})
+	got := strings.Join(nodes, " ")
+	const want = `phpdoc.Block phpdoc.TextLine phpdoc.TemplateTag \Countable ` +
+		`phpdoc.ParamTag phptype.Param phptype.Union T null ` +
+		`phpdoc.MethodTag Foo phptype.Param int phpdoc.OtherTag`
+	if got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestInspectPartialTags(t *testing.T) {
+	block := &phpdoc.Block{Lines: []phpdoc.Line{
+		new(phpdoc.ParamTag),
+		new(phpdoc.ReturnTag),
+		new(phpdoc.PropertyTag),
+		new(phpdoc.MethodTag),
+		new(phpdoc.VarTag),
+		new(phpdoc.ThrowsTag),
+		new(phpdoc.ExtendsTag),
+		new(phpdoc.ImplementsTag),
+		new(phpdoc.UsesTag),
+		new(phpdoc.TemplateTag),
+		new(phpdoc.TypeDefTag),
+		new(phpdoc.ImportTypeTag),
+		new(phpdoc.MixinTag),
+		new(phpdoc.ParamOutTag),
+		new(phpdoc.AssertTag),
+		new(phpdoc.SelfOutTag),
+	}}
+	n := 0
+	phpdoc.Inspect(block, func(node phpdoc.Node) bool {
+		if node != nil {
+			n++
+		}
+		return true
+	})
+	if want := 1 + len(block.Lines); n != want {
+		t.Errorf("visited %d nodes, want %d", n, want)
+	}
+	phpdoc.ResolveNames(block, new(phptype.Scope))
+}